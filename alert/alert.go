@@ -0,0 +1,68 @@
+// Package alert evaluates rules against the nodes and global statistics
+// and sends their alerts to the notifiers (e.g. webhook, smtp or exec)
+package alert
+
+import (
+	"fmt"
+
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+// Status of an alert
+type Status string
+
+// status of the alerts
+const (
+	StatusFiring   Status = "firing"
+	StatusResolved Status = "resolved"
+)
+
+// Alert is a rule, which matches a node or a site
+type Alert struct {
+	Rule      string        `json:"rule"`
+	Status    Status        `json:"status"`
+	Subject   string        `json:"subject"`            // node ID or site (with domain)
+	Hostname  string        `json:"hostname,omitempty"` // hostname of the node
+	Condition string        `json:"condition"`          // description of the rule, e.g. load > 3 for 15m
+	Value     float64       `json:"value"`              // last value of the metric
+	Since     jsontime.Time `json:"since"`              // start of the matching condition
+	Time      jsontime.Time `json:"time"`               // time of the notification
+}
+
+// Summary is a short description of the alert (e.g. for the subject of a mail)
+func (alert *Alert) Summary() string {
+	subject := alert.Subject
+	if alert.Hostname != "" {
+		subject = fmt.Sprintf("%s (%s)", alert.Hostname, alert.Subject)
+	}
+	return fmt.Sprintf("[%s] %s: %s", alert.Status, alert.Rule, subject)
+}
+
+// Text is a description of the alert with all its values
+func (alert *Alert) Text() string {
+	return fmt.Sprintf("%s\n\ncondition: %s\nvalue: %g\nsince: %s\ntime: %s\n",
+		alert.Summary(),
+		alert.Condition,
+		alert.Value,
+		alert.Since.GetTime().Format(jsontime.TimeFormat),
+		alert.Time.GetTime().Format(jsontime.TimeFormat),
+	)
+}
+
+// Notifier sends alerts
+type Notifier interface {
+	// Notify sends the alert on every change of its status
+	// and if it is still firing after the repeat_interval
+	Notify(alert *Alert) error
+}
+
+// Register function with config to get a notifier
+type Register func(config map[string]interface{}) (Notifier, error)
+
+// Notifiers is the list of registered notifiers
+var Notifiers = map[string]Register{}
+
+// RegisterNotifier registers a type of notifier
+func RegisterNotifier(name string, n Register) {
+	Notifiers[name] = n
+}
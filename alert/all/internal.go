@@ -0,0 +1,40 @@
+package all
+
+import (
+	"time"
+
+	"github.com/FreifunkBremen/yanic/alert"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+var engine *alert.Engine
+
+// Start evaluates the rules every interval (default: saveInterval) and sends the alerts to the notifiers
+func Start(nodes *runtime.Nodes, config alert.Config, saveInterval time.Duration) error {
+	if !config.Enable {
+		return nil
+	}
+	notifier, err := Register(config.Notifier)
+	if err != nil {
+		return err
+	}
+	engine, err = alert.NewEngine(config, notifier)
+	if err != nil {
+		return err
+	}
+
+	interval := config.Interval.Duration
+	if interval == 0 {
+		interval = saveInterval
+	}
+	engine.Start(nodes, interval)
+	return nil
+}
+
+// Close stops the evaluation of the last Start
+func Close() {
+	if engine != nil {
+		engine.Close()
+		engine = nil
+	}
+}
@@ -0,0 +1,7 @@
+package all
+
+import (
+	_ "github.com/FreifunkBremen/yanic/alert/exec"
+	_ "github.com/FreifunkBremen/yanic/alert/smtp"
+	_ "github.com/FreifunkBremen/yanic/alert/webhook"
+)
@@ -0,0 +1,58 @@
+package all
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/FreifunkBremen/yanic/alert"
+)
+
+type Notifier struct {
+	alert.Notifier
+	list []alert.Notifier
+}
+
+// Register creates all enabled notifiers of the configuration
+func Register(configuration map[string]interface{}) (alert.Notifier, error) {
+	var list []alert.Notifier
+	for notifierType, notifierRegister := range alert.Notifiers {
+		configForNotifier := configuration[notifierType]
+		if configForNotifier == nil {
+			log.Printf("the notifier type '%s' has no configuration\n", notifierType)
+			continue
+		}
+		notifierConfigs, ok := configForNotifier.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the notifier type '%s' has the wrong format", notifierType)
+		}
+		for _, notifierConfig := range notifierConfigs {
+			config, ok := notifierConfig.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("the notifier type '%s' has the wrong format", notifierType)
+			}
+			if c, ok := config["enable"].(bool); ok && !c {
+				continue
+			}
+			notifier, err := notifierRegister(config)
+			if err != nil {
+				return nil, fmt.Errorf("notifier %s: %s", notifierType, err)
+			}
+			list = append(list, notifier)
+		}
+	}
+	return &Notifier{list: list}, nil
+}
+
+// Notify sends the alert to all notifiers, a failed notifier does not stop the others
+func (n *Notifier) Notify(a *alert.Alert) error {
+	var errs []error
+	for _, item := range n.list {
+		if err := item.Notify(a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
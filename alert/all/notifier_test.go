@@ -0,0 +1,82 @@
+package all
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/alert"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type testNotifier struct {
+	alert.Notifier
+	alerts []*alert.Alert
+	err    error
+}
+
+func (n *testNotifier) Notify(a *alert.Alert) error {
+	n.alerts = append(n.alerts, a)
+	return n.err
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	var notifiers []*testNotifier
+	alert.RegisterNotifier("test", func(config map[string]interface{}) (alert.Notifier, error) {
+		if _, ok := config["fail"]; ok {
+			return nil, errors.New("failed")
+		}
+		notifier := &testNotifier{}
+		if _, ok := config["error"]; ok {
+			notifier.err = errors.New("not sent")
+		}
+		notifiers = append(notifiers, notifier)
+		return notifier, nil
+	})
+	defer delete(alert.Notifiers, "test")
+
+	_, err := Register(map[string]interface{}{"test": "wrong"})
+	assert.Error(err)
+	_, err = Register(map[string]interface{}{"test": []interface{}{"wrong"}})
+	assert.Error(err)
+	_, err = Register(map[string]interface{}{"test": []interface{}{map[string]interface{}{"fail": true}}})
+	assert.Error(err)
+
+	notifier, err := Register(map[string]interface{}{
+		"test": []interface{}{
+			map[string]interface{}{"error": true},
+			map[string]interface{}{},
+			map[string]interface{}{"enable": false},
+		},
+	})
+	assert.NoError(err)
+	assert.Len(notifiers, 2)
+
+	assert.Error(notifier.Notify(&alert.Alert{Rule: "test"}))
+	assert.Len(notifiers[0].alerts, 1)
+	assert.Len(notifiers[1].alerts, 1, "a failed notifier does not stop the others")
+}
+
+func TestStart(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	assert.NoError(Start(nodes, alert.Config{}, time.Minute))
+	assert.Nil(engine)
+
+	config := alert.Config{
+		Enable: true,
+		Rules:  []alert.RuleConfig{{Name: "load", Metric: "load", Operator: ">", Value: 3}},
+	}
+	assert.NoError(Start(nodes, config, time.Minute))
+	assert.NotNil(engine)
+	Close()
+	assert.Nil(engine)
+
+	config.Rules[0].Metric = "unknown"
+	assert.Error(Start(nodes, config, time.Minute))
+}
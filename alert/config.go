@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"fmt"
+
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+// Config of the alerting
+type Config struct {
+	Enable         bool                   `toml:"enable"`
+	Interval       duration.Duration      `toml:"interval"`        // Evaluate the rules periodically (default: save_interval of [nodes])
+	RepeatInterval duration.Duration      `toml:"repeat_interval"` // Notify still firing alerts again after this period (default: never)
+	Rules          []RuleConfig           `toml:"rule"`
+	Notifier       map[string]interface{} `toml:"notifier"`
+}
+
+// RuleConfig is the definition of a rule
+type RuleConfig struct {
+	Name     string            `toml:"name"`
+	Target   string            `toml:"target"`   // node (default) or site
+	Metric   string            `toml:"metric"`   // e.g. load or clients
+	Operator string            `toml:"operator"` // >, >=, <, <=, ==, != or drop
+	Value    Number            `toml:"value"`    // threshold, for drop the share of the decrease (e.g. 0.5)
+	For      duration.Duration `toml:"for"`      // the condition has to match this period before the alert fires
+	Window   duration.Duration `toml:"window"`   // for drop: period of the values to compare with (default: 1h)
+	Site     string            `toml:"site"`     // site code of the nodes or of the site statistics
+	Domain   string            `toml:"domain"`   // domain code of the nodes or of the site statistics
+	Gateway  bool              `toml:"gateway"`  // only gateways
+}
+
+// Number is a float, which accepts integers in the configuration as well
+type Number float64
+
+// UnmarshalTOML reads an integer or a float
+func (n *Number) UnmarshalTOML(decode func(interface{}) error) error {
+	var value interface{}
+	if err := decode(&value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case int64:
+		*n = Number(value)
+	case float64:
+		*n = Number(value)
+	default:
+		return fmt.Errorf("invalid number: %v", value)
+	}
+	return nil
+}
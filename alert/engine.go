@@ -0,0 +1,158 @@
+package alert
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// state of a subject, which matches a rule
+type state struct {
+	since    jsontime.Time // start of the matching condition
+	firing   bool
+	notified jsontime.Time // last notification
+	last     observation   // last matching value
+}
+
+// Engine evaluates the rules and notifies on changes of the alerts,
+// an alert is sent once when it starts firing and once when it is resolved
+type Engine struct {
+	rules          []*Rule
+	notifier       Notifier
+	repeatInterval time.Duration
+	states         map[string]map[string]*state // by rule and subject
+
+	stop chan interface{}
+	wg   sync.WaitGroup
+}
+
+// NewEngine validates the rules of the config
+func NewEngine(config Config, notifier Notifier) (*Engine, error) {
+	engine := &Engine{
+		notifier:       notifier,
+		repeatInterval: config.RepeatInterval.Duration,
+		states:         make(map[string]map[string]*state),
+	}
+	for _, ruleConfig := range config.Rules {
+		rule, err := NewRule(ruleConfig)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := engine.states[rule.Name]; ok {
+			return nil, fmt.Errorf("rule %s is configured twice", rule.Name)
+		}
+		engine.rules = append(engine.rules, rule)
+		engine.states[rule.Name] = make(map[string]*state)
+	}
+	return engine, nil
+}
+
+// Start evaluates the rules periodically until Close
+func (engine *Engine) Start(nodes *runtime.Nodes, interval time.Duration) {
+	engine.stop = make(chan interface{})
+	engine.wg.Add(1)
+	go func() {
+		defer engine.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				engine.notify(engine.Evaluate(nodes, jsontime.Now()))
+			case <-engine.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the evaluation
+func (engine *Engine) Close() {
+	if engine.stop != nil {
+		close(engine.stop)
+		engine.wg.Wait()
+		engine.stop = nil
+	}
+}
+
+// Evaluate evaluates all rules and returns the alerts to notify
+func (engine *Engine) Evaluate(nodes *runtime.Nodes, now jsontime.Time) []*Alert {
+	stats := runtime.NewGlobalStats(nodes, engine.sitesDomains())
+
+	var alerts []*Alert
+	for _, rule := range engine.rules {
+		states := engine.states[rule.Name]
+		matches := rule.evaluate(nodes, stats, now.GetTime())
+
+		for subject, observed := range matches {
+			current := states[subject]
+			if current == nil {
+				current = &state{since: now}
+				states[subject] = current
+			}
+			current.last = observed
+			if !current.firing && now.GetTime().Sub(current.since.GetTime()) < rule.For.Duration {
+				// pending
+				continue
+			}
+			if !current.firing || (engine.repeatInterval > 0 && now.GetTime().Sub(current.notified.GetTime()) >= engine.repeatInterval) {
+				current.firing = true
+				current.notified = now
+				alerts = append(alerts, newAlert(rule, subject, current, StatusFiring, now))
+			}
+		}
+
+		for subject, current := range states {
+			if _, ok := matches[subject]; ok {
+				continue
+			}
+			if current.firing {
+				alerts = append(alerts, newAlert(rule, subject, current, StatusResolved, now))
+			}
+			delete(states, subject)
+		}
+	}
+	return alerts
+}
+
+// notify sends the alerts, errors are logged
+func (engine *Engine) notify(alerts []*Alert) {
+	for _, alert := range alerts {
+		if err := engine.notifier.Notify(alert); err != nil {
+			log.Printf("failed to notify the alert %s: %s", alert.Summary(), err)
+		}
+	}
+}
+
+// sitesDomains returns the sites and domains of the site rules for the global statistics
+func (engine *Engine) sitesDomains() map[string][]string {
+	sitesDomains := make(map[string][]string)
+	for _, rule := range engine.rules {
+		if rule.Target != TargetSite || rule.Site == runtime.GLOBAL_SITE {
+			continue
+		}
+		domains := sitesDomains[rule.Site]
+		if rule.Domain != runtime.GLOBAL_DOMAIN {
+			domains = append(domains, rule.Domain)
+		}
+		sitesDomains[rule.Site] = domains
+	}
+	return sitesDomains
+}
+
+func newAlert(rule *Rule, subject string, current *state, status Status, now jsontime.Time) *Alert {
+	return &Alert{
+		Rule:      rule.Name,
+		Status:    status,
+		Subject:   subject,
+		Hostname:  current.last.hostname,
+		Condition: rule.Condition(),
+		Value:     current.last.value,
+		Since:     current.since,
+		Time:      now,
+	}
+}
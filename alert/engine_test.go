@@ -0,0 +1,139 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type testNotifier struct {
+	Notifier
+	alerts chan *Alert
+}
+
+func (n *testNotifier) Notify(alert *Alert) error {
+	n.alerts <- alert
+	return nil
+}
+
+func TestEngine(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewEngine(Config{Rules: []RuleConfig{{Name: "test", Metric: "unknown"}}}, nil)
+	assert.Error(err)
+	_, err = NewEngine(Config{Rules: []RuleConfig{
+		{Name: "test", Metric: "load", Operator: ">"},
+		{Name: "test", Metric: "load", Operator: "<"},
+	}}, nil)
+	assert.Error(err, "duplicated name")
+
+	config := Config{Rules: []RuleConfig{{Name: "load", Metric: "load", Operator: ">", Value: 3}}}
+	config.Rules[0].For.Duration = time.Minute * 15
+	config.RepeatInterval.Duration = time.Hour
+	engine, err := NewEngine(config, nil)
+	assert.NoError(err)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	update := func(load float64) {
+		nodes.Update("f81a67a601ea", &data.ResponseData{
+			NodeInfo:   &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "node"},
+			Statistics: &data.Statistics{LoadAverage: load},
+		})
+	}
+	now := jsontime.Now()
+
+	update(4)
+	assert.Len(engine.Evaluate(nodes, now), 0, "pending")
+	assert.Len(engine.Evaluate(nodes, now.Add(time.Minute*10)), 0, "pending")
+
+	alerts := engine.Evaluate(nodes, now.Add(time.Minute*15))
+	assert.Len(alerts, 1)
+	alert := alerts[0]
+	assert.Equal("load", alert.Rule)
+	assert.Equal(StatusFiring, alert.Status)
+	assert.Equal("f81a67a601ea", alert.Subject)
+	assert.Equal("node", alert.Hostname)
+	assert.Equal(4.0, alert.Value)
+	assert.Equal(now, alert.Since)
+
+	// deduplicated
+	assert.Len(engine.Evaluate(nodes, now.Add(time.Minute*20)), 0)
+
+	// repeated
+	alerts = engine.Evaluate(nodes, now.Add(time.Minute*75))
+	assert.Len(alerts, 1)
+	assert.Equal(StatusFiring, alerts[0].Status)
+
+	update(1)
+	alerts = engine.Evaluate(nodes, now.Add(time.Minute*80))
+	assert.Len(alerts, 1)
+	assert.Equal(StatusResolved, alerts[0].Status)
+	assert.Equal(4.0, alerts[0].Value, "last matching value")
+	assert.Len(engine.Evaluate(nodes, now.Add(time.Minute*81)), 0)
+
+	// a short match is not notified
+	update(4)
+	assert.Len(engine.Evaluate(nodes, now.Add(time.Minute*90)), 0)
+	update(1)
+	assert.Len(engine.Evaluate(nodes, now.Add(time.Minute*91)), 0)
+}
+
+func TestEngineSite(t *testing.T) {
+	assert := assert.New(t)
+
+	engine, err := NewEngine(Config{Rules: []RuleConfig{
+		{Name: "clients", Target: TargetSite, Metric: "clients", Operator: "drop", Value: 0.5, Site: "ffhb"},
+		{Name: "gateways", Target: TargetSite, Metric: "gateways", Operator: "<", Value: 1},
+	}}, nil)
+	assert.NoError(err)
+	assert.Equal(map[string][]string{"ffhb": nil}, engine.sitesDomains())
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	update := func(clients uint32) {
+		nodeinfo := &data.NodeInfo{NodeID: "f81a67a601ea"}
+		nodeinfo.System.SiteCode = "ffhb"
+		nodes.Update("f81a67a601ea", &data.ResponseData{
+			NodeInfo:   nodeinfo,
+			Statistics: &data.Statistics{Clients: data.Clients{Total: clients}},
+		})
+	}
+	now := jsontime.Now()
+
+	update(20)
+	alerts := engine.Evaluate(nodes, now)
+	assert.Len(alerts, 1)
+	assert.Equal("gateways", alerts[0].Rule)
+	assert.Equal("global", alerts[0].Subject)
+
+	update(8)
+	alerts = engine.Evaluate(nodes, now.Add(time.Minute))
+	assert.Len(alerts, 1)
+	assert.Equal("clients", alerts[0].Rule)
+	assert.Equal("ffhb", alerts[0].Subject)
+	assert.Equal(8.0, alerts[0].Value)
+}
+
+func TestEngineStart(t *testing.T) {
+	assert := assert.New(t)
+
+	notifier := &testNotifier{alerts: make(chan *Alert, 1)}
+	engine, err := NewEngine(Config{Rules: []RuleConfig{{Name: "offline", Metric: "online", Operator: "==", Value: 0}}}, notifier)
+	assert.NoError(err)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	nodes.AddNode(&runtime.Node{Nodeinfo: &data.NodeInfo{NodeID: "f81a67a601ea"}})
+
+	engine.Start(nodes, time.Millisecond*10)
+	alert := <-notifier.alerts
+	engine.Close()
+	engine.Close()
+
+	assert.Equal("offline", alert.Rule)
+	assert.Equal("[firing] offline: f81a67a601ea", alert.Summary())
+	assert.Contains(alert.Text(), "condition: online == 0")
+}
@@ -0,0 +1,107 @@
+// Package exec runs a command for every alert
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"strings"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/alert"
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+const timeoutDefault = time.Second * 10
+
+type Notifier struct {
+	alert.Notifier
+	command string
+	args    []string
+	timeout time.Duration
+}
+
+type Config map[string]interface{}
+
+// Command to run
+func (c Config) Command() string {
+	command, _ := c["command"].(string)
+	return command
+}
+
+// Args of the command
+func (c Config) Args() []string {
+	var args []string
+	list, _ := c["args"].([]interface{})
+	for _, item := range list {
+		args = append(args, fmt.Sprint(item))
+	}
+	return args
+}
+
+// Timeout after which the command is killed
+func (c Config) Timeout() (time.Duration, error) {
+	value, ok := c["timeout"].(string)
+	if !ok || value == "" {
+		return timeoutDefault, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid timeout: %s", err)
+	}
+	return d.Duration, nil
+}
+
+func init() {
+	alert.RegisterNotifier("exec", Register)
+}
+
+func Register(configuration map[string]interface{}) (alert.Notifier, error) {
+	var config Config
+	config = configuration
+
+	command := config.Command()
+	if command == "" {
+		return nil, errors.New("no command given")
+	}
+	timeout, err := config.Timeout()
+	if err != nil {
+		return nil, err
+	}
+	return &Notifier{
+		command: command,
+		args:    config.Args(),
+		timeout: timeout,
+	}, nil
+}
+
+// Notify runs the command with the alert as JSON on stdin and its values as environment variables
+func (n *Notifier) Notify(a *alert.Alert) error {
+	input, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+	defer cancel()
+
+	cmd := osexec.CommandContext(ctx, n.command, n.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Env = append(os.Environ(),
+		"YANIC_ALERT_RULE="+a.Rule,
+		"YANIC_ALERT_STATUS="+string(a.Status),
+		"YANIC_ALERT_SUBJECT="+a.Subject,
+		"YANIC_ALERT_HOSTNAME="+a.Hostname,
+		"YANIC_ALERT_CONDITION="+a.Condition,
+		fmt.Sprintf("YANIC_ALERT_VALUE=%g", a.Value),
+		"YANIC_ALERT_SUMMARY="+a.Summary(),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %s %s", n.command, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
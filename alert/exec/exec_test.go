@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/alert"
+)
+
+func TestExec(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{})
+	assert.Error(err)
+	_, err = Register(map[string]interface{}{"command": "true", "timeout": "1x"})
+	assert.Error(err)
+
+	dir, err := ioutil.TempDir("", "yanic-exec")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "alert")
+
+	notifier, err := Register(map[string]interface{}{
+		"command": "sh",
+		"args":    []interface{}{"-c", `cat > "$0"; echo "$YANIC_ALERT_STATUS $YANIC_ALERT_RULE" > "$0.env"`, path},
+	})
+	assert.NoError(err)
+	assert.NoError(notifier.Notify(&alert.Alert{
+		Rule:    "rootfs",
+		Status:  alert.StatusResolved,
+		Subject: "f81a67a601ea",
+		Value:   0.5,
+	}))
+
+	raw, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	a := &alert.Alert{}
+	assert.NoError(json.Unmarshal(raw, a))
+	assert.Equal("f81a67a601ea", a.Subject)
+	assert.Equal(0.5, a.Value)
+
+	raw, err = ioutil.ReadFile(path + ".env")
+	assert.NoError(err)
+	assert.Equal("resolved rootfs", strings.TrimSpace(string(raw)))
+
+	notifier, err = Register(map[string]interface{}{
+		"command": "sh",
+		"args":    []interface{}{"-c", "echo failed; exit 1"},
+	})
+	assert.NoError(err)
+	err = notifier.Notify(&alert.Alert{})
+	assert.Error(err)
+	assert.Contains(err.Error(), "failed")
+
+	notifier, err = Register(map[string]interface{}{
+		"command": "sleep",
+		"args":    []interface{}{"10"},
+		"timeout": "1s",
+	})
+	assert.NoError(err)
+	assert.Error(notifier.Notify(&alert.Alert{}))
+}
@@ -0,0 +1,253 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// targets of the rules
+const (
+	TargetNode = "node"
+	TargetSite = "site"
+)
+
+// windowDefault is the period of the values to compare with for the operator drop
+const windowDefault = time.Hour
+
+// nodeMetrics returns the value of a node, false if it has no current value
+var nodeMetrics = map[string]func(node *runtime.Node, now time.Time) (float64, bool){
+	"online": func(node *runtime.Node, now time.Time) (float64, bool) {
+		if node.Online {
+			return 1, true
+		}
+		return 0, true
+	},
+	"lastseen": func(node *runtime.Node, now time.Time) (float64, bool) {
+		return now.Sub(node.Lastseen.GetTime()).Seconds(), true
+	},
+	"load": func(node *runtime.Node, now time.Time) (float64, bool) {
+		if stats := currentStatistics(node); stats != nil {
+			return stats.LoadAverage, true
+		}
+		return 0, false
+	},
+	"rootfs_usage": func(node *runtime.Node, now time.Time) (float64, bool) {
+		if stats := currentStatistics(node); stats != nil {
+			return stats.RootFsUsage, true
+		}
+		return 0, false
+	},
+	"memory_usage": func(node *runtime.Node, now time.Time) (float64, bool) {
+		if stats := currentStatistics(node); stats != nil && stats.Memory.Total > 0 {
+			memory := stats.Memory
+			return 1 - float64(memory.Free+memory.Buffers+memory.Cached)/float64(memory.Total), true
+		}
+		return 0, false
+	},
+	"clients": func(node *runtime.Node, now time.Time) (float64, bool) {
+		if stats := currentStatistics(node); stats != nil {
+			return float64(stats.Clients.Total), true
+		}
+		return 0, false
+	},
+	"uptime": func(node *runtime.Node, now time.Time) (float64, bool) {
+		if stats := currentStatistics(node); stats != nil {
+			return stats.Uptime, true
+		}
+		return 0, false
+	},
+}
+
+// siteMetrics returns the value of the statistics of a site
+var siteMetrics = map[string]func(stats *runtime.GlobalStats) float64{
+	"clients": func(stats *runtime.GlobalStats) float64 {
+		return float64(stats.Clients)
+	},
+	"nodes": func(stats *runtime.GlobalStats) float64 {
+		return float64(stats.Nodes)
+	},
+	"gateways": func(stats *runtime.GlobalStats) float64 {
+		return float64(stats.Gateways)
+	},
+}
+
+var operators = map[string]func(value, threshold float64) bool{
+	">":  func(value, threshold float64) bool { return value > threshold },
+	">=": func(value, threshold float64) bool { return value >= threshold },
+	"<":  func(value, threshold float64) bool { return value < threshold },
+	"<=": func(value, threshold float64) bool { return value <= threshold },
+	"==": func(value, threshold float64) bool { return value == threshold },
+	"!=": func(value, threshold float64) bool { return value != threshold },
+}
+
+// operatorDrop matches a decrease of the value by the share of the threshold
+// in regard to the highest value within the window
+const operatorDrop = "drop"
+
+// currentStatistics returns the statistics of an online node
+func currentStatistics(node *runtime.Node) *data.Statistics {
+	if node.Online {
+		return node.Statistics
+	}
+	return nil
+}
+
+// observation is the value of a subject (node or site), which matches the rule
+type observation struct {
+	value    float64
+	hostname string
+}
+
+type sample struct {
+	time  time.Time
+	value float64
+}
+
+// Rule is a validated rule with the values of the operator drop
+type Rule struct {
+	RuleConfig
+	samples map[string][]sample // values within the window by subject
+}
+
+// NewRule validates the configuration of a rule
+func NewRule(config RuleConfig) (*Rule, error) {
+	if config.Name == "" {
+		return nil, errors.New("every rule needs a name")
+	}
+	if config.Target == "" {
+		config.Target = TargetNode
+	}
+	switch config.Target {
+	case TargetNode:
+		if _, ok := nodeMetrics[config.Metric]; !ok {
+			return nil, fmt.Errorf("rule %s: unknown metric '%s' of a node", config.Name, config.Metric)
+		}
+	case TargetSite:
+		if _, ok := siteMetrics[config.Metric]; !ok {
+			return nil, fmt.Errorf("rule %s: unknown metric '%s' of a site", config.Name, config.Metric)
+		}
+		if config.Site == "" {
+			config.Site = runtime.GLOBAL_SITE
+		}
+		if config.Domain == "" {
+			config.Domain = runtime.GLOBAL_DOMAIN
+		}
+	default:
+		return nil, fmt.Errorf("rule %s: unknown target '%s'", config.Name, config.Target)
+	}
+	if _, ok := operators[config.Operator]; !ok && config.Operator != operatorDrop {
+		return nil, fmt.Errorf("rule %s: unknown operator '%s'", config.Name, config.Operator)
+	}
+	if config.Window.Duration == 0 {
+		config.Window.Duration = windowDefault
+	}
+	return &Rule{
+		RuleConfig: config,
+		samples:    make(map[string][]sample),
+	}, nil
+}
+
+// Condition describes the rule, e.g. load > 3 for 15m
+func (rule *Rule) Condition() string {
+	var condition string
+	if rule.Operator == operatorDrop {
+		condition = fmt.Sprintf("%s dropped by %g%% within %s", rule.Metric, float64(rule.Value)*100, rule.Window.Duration)
+	} else {
+		condition = fmt.Sprintf("%s %s %g", rule.Metric, rule.Operator, float64(rule.Value))
+	}
+	if rule.For.Duration > 0 {
+		condition += fmt.Sprintf(" for %s", rule.For.Duration)
+	}
+	return condition
+}
+
+// evaluate returns the subjects, which match the rule
+func (rule *Rule) evaluate(nodes *runtime.Nodes, stats map[string]map[string]*runtime.GlobalStats, now time.Time) map[string]observation {
+	values := make(map[string]observation)
+	if rule.Target == TargetSite {
+		if siteStats := stats[rule.Site][rule.Domain]; siteStats != nil {
+			values[rule.siteSubject()] = observation{value: siteMetrics[rule.Metric](siteStats)}
+		}
+	} else {
+		metric := nodeMetrics[rule.Metric]
+		nodes.RLock()
+		for nodeID, node := range nodes.List {
+			if !rule.selects(node) {
+				continue
+			}
+			if value, ok := metric(node, now); ok {
+				values[nodeID] = observation{value: value, hostname: node.Nodeinfo.Hostname}
+			}
+		}
+		nodes.RUnlock()
+	}
+
+	matches := make(map[string]observation)
+	for subject, observed := range values {
+		if rule.matches(subject, observed.value, now) {
+			matches[subject] = observed
+		}
+	}
+	if rule.Operator == operatorDrop {
+		// forget the values of removed subjects
+		for subject := range rule.samples {
+			if _, ok := values[subject]; !ok {
+				delete(rule.samples, subject)
+			}
+		}
+	}
+	return matches
+}
+
+// selects returns true if the node is a subject of the rule
+func (rule *Rule) selects(node *runtime.Node) bool {
+	info := node.Nodeinfo
+	if info == nil {
+		return false
+	}
+	if rule.Gateway && !node.IsGateway() {
+		return false
+	}
+	if rule.Site != "" && info.System.SiteCode != rule.Site {
+		return false
+	}
+	if rule.Domain != "" && info.System.DomainCode != rule.Domain {
+		return false
+	}
+	return true
+}
+
+// matches returns true if the value matches the operator
+func (rule *Rule) matches(subject string, value float64, now time.Time) bool {
+	threshold := float64(rule.Value)
+	if compare, ok := operators[rule.Operator]; ok {
+		return compare(value, threshold)
+	}
+
+	// drop
+	samples := append(rule.samples[subject], sample{time: now, value: value})
+	windowStart := now.Add(-rule.Window.Duration)
+	for len(samples) > 0 && samples[0].time.Before(windowStart) {
+		samples = samples[1:]
+	}
+	rule.samples[subject] = samples
+
+	highest := value
+	for _, s := range samples {
+		if s.value > highest {
+			highest = s.value
+		}
+	}
+	return highest > 0 && value <= highest*(1-threshold)
+}
+
+func (rule *Rule) siteSubject() string {
+	if rule.Domain == runtime.GLOBAL_DOMAIN {
+		return rule.Site
+	}
+	return rule.Site + "/" + rule.Domain
+}
@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestNewRule(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewRule(RuleConfig{Metric: "load", Operator: ">"})
+	assert.Error(err, "no name")
+	_, err = NewRule(RuleConfig{Name: "test", Metric: "unknown", Operator: ">"})
+	assert.Error(err)
+	_, err = NewRule(RuleConfig{Name: "test", Target: "site", Metric: "load", Operator: ">"})
+	assert.Error(err)
+	_, err = NewRule(RuleConfig{Name: "test", Target: "unknown", Metric: "load", Operator: ">"})
+	assert.Error(err)
+	_, err = NewRule(RuleConfig{Name: "test", Metric: "load", Operator: "~"})
+	assert.Error(err)
+
+	rule, err := NewRule(RuleConfig{Name: "test", Metric: "load", Operator: ">", Value: 3})
+	assert.NoError(err)
+	assert.Equal(TargetNode, rule.Target)
+	assert.Equal("load > 3", rule.Condition())
+
+	rule, err = NewRule(RuleConfig{Name: "test", Target: "site", Metric: "clients", Operator: "drop", Value: 0.5})
+	assert.NoError(err)
+	assert.Equal(runtime.GLOBAL_SITE, rule.Site)
+	assert.Equal("clients dropped by 50% within 1h0m0s", rule.Condition())
+}
+
+func TestNodeMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	node := &runtime.Node{
+		Online: true,
+		Statistics: &data.Statistics{
+			LoadAverage: 1.5,
+			RootFsUsage: 0.9,
+			Memory:      data.Memory{Total: 100, Free: 50},
+			Clients:     data.Clients{Total: 7},
+			Uptime:      60,
+		},
+	}
+	for metric, expected := range map[string]float64{
+		"online":       1,
+		"load":         1.5,
+		"rootfs_usage": 0.9,
+		"memory_usage": 0.5,
+		"clients":      7,
+		"uptime":       60,
+	} {
+		value, ok := nodeMetrics[metric](node, now)
+		assert.True(ok, metric)
+		assert.Equal(expected, value, metric)
+	}
+
+	// no current statistics of an offline node
+	node.Online = false
+	_, ok := nodeMetrics["load"](node, now)
+	assert.False(ok)
+	value, ok := nodeMetrics["online"](node, now)
+	assert.True(ok)
+	assert.Equal(0.0, value)
+}
+
+func TestRuleSelects(t *testing.T) {
+	assert := assert.New(t)
+
+	rule, _ := NewRule(RuleConfig{Name: "test", Metric: "online", Operator: "==", Gateway: true, Site: "ffhb"})
+	assert.False(rule.selects(&runtime.Node{}))
+
+	node := &runtime.Node{Nodeinfo: &data.NodeInfo{}}
+	node.Nodeinfo.System.SiteCode = "ffhb"
+	assert.False(rule.selects(node), "not a gateway")
+	node.Nodeinfo.VPN = true
+	assert.True(rule.selects(node))
+	node.Nodeinfo.System.SiteCode = "other"
+	assert.False(rule.selects(node))
+}
+
+func TestRuleDrop(t *testing.T) {
+	assert := assert.New(t)
+
+	rule, _ := NewRule(RuleConfig{Name: "test", Target: "site", Metric: "clients", Operator: "drop", Value: 0.5})
+	rule.Window.Duration = time.Hour
+	now := time.Now()
+
+	assert.False(rule.matches("global", 100, now))
+	assert.False(rule.matches("global", 60, now.Add(time.Minute)))
+	assert.True(rule.matches("global", 50, now.Add(time.Minute*2)))
+
+	// the highest value is out of the window
+	assert.False(rule.matches("global", 50, now.Add(time.Hour+time.Minute*30)))
+
+	// nothing to compare with
+	assert.False(rule.matches("other", 0, now))
+}
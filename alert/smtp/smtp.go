@@ -0,0 +1,183 @@
+// Package smtp sends the alerts by mail
+package smtp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/alert"
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+const timeoutDefault = time.Second * 10
+
+type Notifier struct {
+	alert.Notifier
+	address string
+	host    string
+	from    string
+	to      []string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+type Config map[string]interface{}
+
+// Address of the mail server (host:port)
+func (c Config) Address() string {
+	address, _ := c["address"].(string)
+	return address
+}
+
+// From is the sender of the mails
+func (c Config) From() string {
+	from, _ := c["from"].(string)
+	return from
+}
+
+// To are the recipients of the mails
+func (c Config) To() []string {
+	var to []string
+	switch value := c["to"].(type) {
+	case string:
+		to = append(to, value)
+	case []interface{}:
+		for _, item := range value {
+			if recipient, ok := item.(string); ok {
+				to = append(to, recipient)
+			}
+		}
+	}
+	return to
+}
+
+// Username for the authentication (optional)
+func (c Config) Username() string {
+	username, _ := c["username"].(string)
+	return username
+}
+
+// Password for the authentication
+func (c Config) Password() string {
+	password, _ := c["password"].(string)
+	return password
+}
+
+// Timeout of the delivery of a mail
+func (c Config) Timeout() (time.Duration, error) {
+	value, ok := c["timeout"].(string)
+	if !ok || value == "" {
+		return timeoutDefault, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid timeout: %s", err)
+	}
+	return d.Duration, nil
+}
+
+func init() {
+	alert.RegisterNotifier("smtp", Register)
+}
+
+func Register(configuration map[string]interface{}) (alert.Notifier, error) {
+	var config Config
+	config = configuration
+
+	notifier := &Notifier{
+		address: config.Address(),
+		from:    config.From(),
+		to:      config.To(),
+	}
+	if notifier.address == "" || notifier.from == "" || len(notifier.to) == 0 {
+		return nil, errors.New("smtp needs an address, a sender (from) and recipients (to)")
+	}
+	host, _, err := net.SplitHostPort(notifier.address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %s", err)
+	}
+	notifier.host = host
+	if username := config.Username(); username != "" {
+		notifier.auth = smtp.PlainAuth("", username, config.Password(), host)
+	}
+	if notifier.timeout, err = config.Timeout(); err != nil {
+		return nil, err
+	}
+	return notifier, nil
+}
+
+// Notify sends the alert as mail to all recipients (like smtp.SendMail),
+// the whole delivery has to finish within the timeout, as the alerts are sent one after another
+func (n *Notifier) Notify(a *alert.Alert) error {
+	conn, err := net.DialTimeout("tcp", n.address, n.timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(n.timeout)); err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("the mail server does not support AUTH")
+		}
+		if err = client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(n.message(a)); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (n *Notifier) message(a *alert.Alert) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject(a))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(a.Text(), "\n", "\r\n", -1))
+	return msg.Bytes()
+}
+
+// subject of the mail, the summary contains the hostname reported by the node,
+// so line breaks are removed and non-ASCII characters are encoded (RFC 2047)
+func subject(a *alert.Alert) string {
+	summary := strings.NewReplacer("\r", " ", "\n", " ").Replace(a.Summary())
+	return mime.QEncoding.Encode("utf-8", "[yanic] "+summary)
+}
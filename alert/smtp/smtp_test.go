@@ -0,0 +1,152 @@
+package smtp
+
+import (
+	"bufio"
+	"io/ioutil"
+	"mime"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/alert"
+)
+
+// serveSMTP answers one mail like a mail server and returns its commands and data
+func serveSMTP(listener net.Listener, result chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		close(result)
+		return
+	}
+	defer conn.Close()
+
+	var lines []string
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("220 localhost ESMTP\r\n"))
+	data := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		if data {
+			if line == "." {
+				data = false
+				conn.Write([]byte("250 OK\r\n"))
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "DATA"):
+			data = true
+			conn.Write([]byte("354 go ahead\r\n"))
+		case strings.HasPrefix(line, "QUIT"):
+			conn.Write([]byte("221 bye\r\n"))
+			result <- lines
+			return
+		default:
+			conn.Write([]byte("250 localhost\r\n"))
+		}
+	}
+	result <- lines
+}
+
+func TestSMTP(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{"address": "localhost:25"})
+	assert.Error(err)
+	_, err = Register(map[string]interface{}{"address": "localhost", "from": "yanic@example.org", "to": "admin@example.org"})
+	assert.Error(err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+	result := make(chan []string, 1)
+	go serveSMTP(listener, result)
+
+	notifier, err := Register(map[string]interface{}{
+		"address": listener.Addr().String(),
+		"from":    "yanic@example.org",
+		"to":      []interface{}{"admin@example.org", "noc@example.org"},
+	})
+	assert.NoError(err)
+	assert.NoError(notifier.Notify(&alert.Alert{
+		Rule:      "gateway-offline",
+		Status:    alert.StatusFiring,
+		Subject:   "f81a67a601ea",
+		Hostname:  "gw01",
+		Condition: "online == 0 for 5m0s",
+	}))
+
+	mail := strings.Join(<-result, "\n")
+	assert.Contains(mail, "MAIL FROM:<yanic@example.org>")
+	assert.Contains(mail, "RCPT TO:<admin@example.org>")
+	assert.Contains(mail, "RCPT TO:<noc@example.org>")
+	assert.Contains(mail, "Subject: [yanic] [firing] gateway-offline: gw01 (f81a67a601ea)")
+	assert.Contains(mail, "condition: online == 0 for 5m0s")
+
+	// hostile hostname
+	go serveSMTP(listener, result)
+	assert.NoError(notifier.Notify(&alert.Alert{
+		Rule:     "node-offline",
+		Status:   alert.StatusFiring,
+		Subject:  "f81a67a601eb",
+		Hostname: "gw02\r\nBcc: victim@example.org\r\n\r\nGäste",
+	}))
+	var headers []string
+	for _, line := range <-result {
+		if line == "" {
+			break
+		}
+		headers = append(headers, line)
+	}
+	var subject string
+	for _, line := range headers {
+		assert.False(strings.HasPrefix(line, "Bcc:"), line)
+		if strings.HasPrefix(line, "Subject: ") {
+			subject, err = new(mime.WordDecoder).DecodeHeader(strings.TrimPrefix(line, "Subject: "))
+			assert.NoError(err)
+		}
+	}
+	assert.Equal("[yanic] [firing] node-offline: gw02  Bcc: victim@example.org    Gäste (f81a67a601eb)", subject)
+
+	// no mail server
+	listener.Close()
+	assert.Error(notifier.Notify(&alert.Alert{}))
+}
+
+func TestSMTPTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{"address": "localhost:25", "from": "yanic@example.org", "to": "admin@example.org", "timeout": "1x"})
+	assert.Error(err)
+
+	// mail server which never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			ioutil.ReadAll(conn)
+		}
+	}()
+
+	notifier, err := Register(map[string]interface{}{
+		"address": listener.Addr().String(),
+		"from":    "yanic@example.org",
+		"to":      "admin@example.org",
+		"timeout": "1s",
+	})
+	assert.NoError(err)
+	start := time.Now()
+	assert.Error(notifier.Notify(&alert.Alert{}))
+	assert.True(time.Since(start) < 5*time.Second)
+}
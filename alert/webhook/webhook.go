@@ -0,0 +1,82 @@
+// Package webhook posts the alerts as JSON to an URL
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/alert"
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+const timeoutDefault = time.Second * 10
+
+type Notifier struct {
+	alert.Notifier
+	url    string
+	client *http.Client
+}
+
+type Config map[string]interface{}
+
+// URL to post the alerts to
+func (c Config) URL() string {
+	url, _ := c["url"].(string)
+	return url
+}
+
+// Timeout of a request
+func (c Config) Timeout() (time.Duration, error) {
+	value, ok := c["timeout"].(string)
+	if !ok || value == "" {
+		return timeoutDefault, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid timeout: %s", err)
+	}
+	return d.Duration, nil
+}
+
+func init() {
+	alert.RegisterNotifier("webhook", Register)
+}
+
+func Register(configuration map[string]interface{}) (alert.Notifier, error) {
+	var config Config
+	config = configuration
+
+	url := config.URL()
+	if url == "" {
+		return nil, errors.New("no url given")
+	}
+	timeout, err := config.Timeout()
+	if err != nil {
+		return nil, err
+	}
+	return &Notifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Notify posts the alert as JSON
+func (n *Notifier) Notify(a *alert.Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	res, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s of %s", res.Status, n.url)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/alert"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+func TestWebhook(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{})
+	assert.Error(err)
+	_, err = Register(map[string]interface{}{"url": "http://localhost", "timeout": "1x"})
+	assert.Error(err)
+
+	received := make(chan *alert.Alert, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/alert" {
+			http.NotFound(w, r)
+			return
+		}
+		a := &alert.Alert{}
+		assert.Equal("application/json", r.Header.Get("Content-Type"))
+		assert.NoError(json.NewDecoder(r.Body).Decode(a))
+		received <- a
+	}))
+	defer srv.Close()
+
+	notifier, err := Register(map[string]interface{}{"url": srv.URL + "/alert", "timeout": "5s"})
+	assert.NoError(err)
+	assert.NoError(notifier.Notify(&alert.Alert{
+		Rule:    "load",
+		Status:  alert.StatusFiring,
+		Subject: "f81a67a601ea",
+		Value:   3.5,
+		Since:   jsontime.Now(),
+		Time:    jsontime.Now(),
+	}))
+	a := <-received
+	assert.Equal("load", a.Rule)
+	assert.Equal(alert.StatusFiring, a.Status)
+	assert.Equal(3.5, a.Value)
+
+	notifier, err = Register(map[string]interface{}{"url": srv.URL + "/missing"})
+	assert.NoError(err)
+	assert.Error(notifier.Notify(&alert.Alert{}))
+}
@@ -5,9 +5,11 @@ import (
 	"io/ioutil"
 	"os"
 
+	"github.com/FreifunkBremen/yanic/alert"
 	"github.com/FreifunkBremen/yanic/database"
 	"github.com/FreifunkBremen/yanic/respond"
 	"github.com/FreifunkBremen/yanic/runtime"
+	_ "github.com/FreifunkBremen/yanic/runtime/boltstore" // state_type bolt
 	"github.com/FreifunkBremen/yanic/webserver"
 	"github.com/naoina/toml"
 )
@@ -15,9 +17,11 @@ import (
 // Config represents the whole configuration
 type Config struct {
 	Respondd  respond.Config
+	Inputs    map[string]interface{}
 	Webserver webserver.Config
 	Nodes     runtime.NodesConfig
 	Database  database.Config
+	Alerts    alert.Config
 }
 
 var (
@@ -49,5 +53,12 @@ func ReadConfigFile(path string) (config *Config, err error) {
 		return nil, err
 	}
 
+	// sections with a long interval should not be marked as stale between two requests
+	if config.Nodes.SectionStaleAfter.Duration == 0 {
+		if longest := 2 * config.Respondd.LongestInterval(); longest > config.Nodes.OfflineAfter.Duration {
+			config.Nodes.SectionStaleAfter.Duration = longest
+		}
+	}
+
 	return
 }
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/alert"
 )
 
 func TestReadConfig(t *testing.T) {
@@ -24,8 +26,19 @@ func TestReadConfig(t *testing.T) {
 	assert.Contains(config.Respondd.Sites, "ffhb")
 	assert.Contains(config.Respondd.Sites["ffhb"].Domains, "city")
 
+	// Test input plugins
+	inputs := config.Inputs["statuspage"].([]interface{})
+	assert.Len(inputs, 1)
+	assert.Equal(false, inputs[0].(map[string]interface{})["enable"])
+	inputs = config.Inputs["federation"].([]interface{})
+	assert.Len(inputs, 1)
+	assert.Len(inputs[0].(map[string]interface{})["remote"], 1)
+	inputs = config.Inputs["receiver"].([]interface{})
+	assert.Len(inputs, 1)
+	assert.Len(inputs[0].(map[string]interface{})["sender"], 1)
+
 	// Test output plugins
-	assert.Len(config.Nodes.Output, 3)
+	assert.Len(config.Nodes.Output, 6)
 	outputs := config.Nodes.Output["meshviewer"].([]interface{})
 	assert.Len(outputs, 1)
 	meshviewer := outputs[0]
@@ -40,6 +53,16 @@ func TestReadConfig(t *testing.T) {
 		},
 	}, meshviewer)
 
+	// Test alerts
+	assert.False(config.Alerts.Enable)
+	assert.Len(config.Alerts.Rules, 4)
+	assert.Equal(time.Minute*15, config.Alerts.Rules[1].For.Duration)
+	assert.EqualValues(3, config.Alerts.Rules[1].Value)
+	assert.EqualValues(0.9, config.Alerts.Rules[2].Value)
+	assert.Len(config.Alerts.Notifier, 3)
+	_, err = alert.NewEngine(config.Alerts, nil)
+	assert.NoError(err)
+
 	_, err = ReadConfigFile("testdata/config_invalid.toml")
 	assert.Error(err, "not unmarshalable")
 	assert.Contains(err.Error(), "invalid TOML syntax")
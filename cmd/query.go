@@ -16,6 +16,7 @@ var (
 	wait      int
 	port      int
 	ipAddress string
+	sections  []string
 )
 
 // queryCmd represents the query command
@@ -36,14 +37,14 @@ var queryCmd = &cobra.Command{
 				InterfaceName: iface,
 				Port:          port,
 				IPAddress:     ipAddress,
+				Sections:      sections,
 			}
 			ifacesConfigs = append(ifacesConfigs, ifaceConfig)
 		}
 
 		nodes := runtime.NewNodes(&runtime.NodesConfig{})
 
-		sitesDomains := make(map[string][]string)
-		collector := respond.NewCollector(nil, nodes, sitesDomains, ifacesConfigs)
+		collector := respond.NewCollector(nil, nodes, &respond.Config{Interfaces: ifacesConfigs})
 		defer collector.Close()
 		collector.SendPacket(dstAddress)
 
@@ -70,4 +71,5 @@ func init() {
 	queryCmd.Flags().IntVar(&wait, "wait", 1, "Seconds to wait for a response")
 	queryCmd.Flags().IntVar(&port, "port", 0, "define a port to listen (if not set or set to 0 the kernel will use a random free port at its own)")
 	queryCmd.Flags().StringVar(&ipAddress, "ip", "", "ip address which is used for sending (optional - without definition used the link-local address)")
+	queryCmd.Flags().StringSliceVar(&sections, "sections", nil, "respondd sections to request (default nodeinfo,statistics,neighbours)")
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+
+	"github.com/FreifunkBremen/yanic/database"
+	allDatabase "github.com/FreifunkBremen/yanic/database/all"
+	"github.com/FreifunkBremen/yanic/output"
+	allOutput "github.com/FreifunkBremen/yanic/output/all"
+)
+
+var (
+	runningConfig *Config
+	reloadLock    sync.Mutex
+)
+
+// Reload reads the configuration file again and rebuilds the changed outputs,
+// database connections and collector interfaces. The overrides of the nodes are read again as well.
+// If the new configuration is invalid, the running one is kept.
+func Reload() error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	config, err := ReadConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+	return applyConfig(config)
+}
+
+// applyConfig prepares every changed part first and swaps them only if all of them are valid
+func applyConfig(config *Config) error {
+	old := runningConfig
+
+	outputChanged := !reflect.DeepEqual(old.Nodes.Output, config.Nodes.Output) || old.Nodes.SaveInterval != config.Nodes.SaveInterval
+	var outputs output.Output
+	if outputChanged {
+		var err error
+		outputs, err = allOutput.Register(config.Nodes.Output)
+		if err != nil {
+			return fmt.Errorf("invalid output configuration: %s", err)
+		}
+	}
+
+	databaseChanged := !reflect.DeepEqual(old.Database, config.Database)
+	var connection database.Connection
+	if databaseChanged {
+		var err error
+		connection, err = allDatabase.Connect(config.Database.Connection)
+		if err != nil {
+			return fmt.Errorf("invalid database configuration: %s", err)
+		}
+	}
+
+	interfacesChanged := collector != nil && !reflect.DeepEqual(old.Respondd.Interfaces, config.Respondd.Interfaces)
+	if interfacesChanged {
+		if err := collector.SetInterfaces(config.Respondd.Interfaces); err != nil {
+			if connection != nil {
+				connection.Close()
+			}
+			return err
+		}
+		log.Println("reloaded respondd interfaces")
+	}
+
+	if databaseChanged {
+		allDatabase.Reload(connection, config.Database)
+		log.Println("reloaded database connections")
+	}
+	if outputChanged {
+		allOutput.Reload(nodes, config.Nodes, outputs)
+		log.Println("reloaded outputs")
+	}
+	if nodes != nil {
+		// the file might have changed without the configuration
+		if err := nodes.LoadOverrides(config.Nodes.OverridesPath); err != nil {
+			log.Println(err)
+		}
+	}
+
+	logRestartRequired(old, config)
+	runningConfig = config
+	return nil
+}
+
+// logRestartRequired warns about changes which are not applied by a reload
+func logRestartRequired(old, config *Config) {
+	oldRespondd, newRespondd := old.Respondd, config.Respondd
+	if collector != nil {
+		oldRespondd.Interfaces, newRespondd.Interfaces = nil, nil
+	}
+	if !reflect.DeepEqual(oldRespondd, newRespondd) {
+		log.Println("changes of [respondd] require a restart")
+	}
+
+	oldNodes, newNodes := old.Nodes, config.Nodes
+	oldNodes.Output, newNodes.Output = nil, nil
+	oldNodes.SaveInterval = newNodes.SaveInterval
+	oldNodes.OverridesPath = newNodes.OverridesPath
+	if !reflect.DeepEqual(oldNodes, newNodes) {
+		log.Println("changes of [nodes] require a restart")
+	}
+
+	if !reflect.DeepEqual(old.Inputs, config.Inputs) {
+		log.Println("changes of [inputs] require a restart")
+	}
+
+	if !reflect.DeepEqual(old.Webserver, config.Webserver) {
+		log.Println("changes of [webserver] require a restart")
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	allDatabase "github.com/FreifunkBremen/yanic/database/all"
+	allOutput "github.com/FreifunkBremen/yanic/output/all"
+	"github.com/FreifunkBremen/yanic/respond"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+const reloadConfigBase = `
+[respondd]
+collect_interval = "1m"
+[[respondd.interfaces]]
+ifname     = "lo"
+ip_address = "127.0.0.1"
+
+[nodes]
+save_interval = "1m"
+
+[database]
+delete_interval = "1h"
+`
+
+func TestReload(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-reload")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	configPath = filepath.Join(dir, "config.toml")
+
+	writeConfig := func(content string) {
+		assert.NoError(ioutil.WriteFile(configPath, []byte(content), 0644))
+	}
+
+	writeConfig(reloadConfigBase)
+	config, err := ReadConfigFile(configPath)
+	assert.NoError(err)
+	runningConfig = config
+
+	assert.NoError(allDatabase.Start(config.Database))
+	defer allDatabase.Close()
+	nodes = runtime.NewNodes(&config.Nodes)
+	assert.NoError(allOutput.Start(nodes, config.Nodes))
+	defer allOutput.Close()
+	collector = respond.NewCollector(allDatabase.Conn, nodes, &config.Respondd)
+	defer func() {
+		collector.Close()
+		collector = nil
+	}()
+
+	// unchanged
+	assert.NoError(Reload())
+	assert.Equal(config, runningConfig)
+
+	// invalid syntax
+	writeConfig("[respondd")
+	assert.Error(Reload())
+	assert.Equal(config, runningConfig)
+
+	// invalid output filter
+	writeConfig(reloadConfigBase + `
+[[nodes.output.nodelist]]
+enable = true
+path   = "` + filepath.Join(dir, "nodelist.json") + `"
+[nodes.output.nodelist.filter]
+blacklist = true
+`)
+	assert.Error(Reload())
+	assert.Equal(config, runningConfig)
+
+	// invalid interface
+	writeConfig(reloadConfigBase + `
+[[respondd.interfaces]]
+ifname     = "lo"
+ip_address = "no-ip"
+`)
+	assert.Error(Reload())
+	assert.Equal(config, runningConfig)
+
+	// new output, database and interface
+	writeConfig(reloadConfigBase + `
+[[respondd.interfaces]]
+ifname     = "lo"
+ip_address = "::1"
+
+[[nodes.output.nodelist]]
+enable = true
+path   = "` + filepath.Join(dir, "nodelist.json") + `"
+
+[[database.connection.logging]]
+enable = true
+path   = "` + filepath.Join(dir, "database.log") + `"
+`)
+	assert.NoError(Reload())
+	assert.NotEqual(config, runningConfig)
+	assert.Len(runningConfig.Respondd.Interfaces, 2)
+	assert.Contains(runningConfig.Nodes.Output, "nodelist")
+}
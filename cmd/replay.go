@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	allDatabase "github.com/FreifunkBremen/yanic/database/all"
+	allOutput "github.com/FreifunkBremen/yanic/output/all"
+	"github.com/FreifunkBremen/yanic/respond"
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/spf13/cobra"
+)
+
+var replaySpeed float64
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:     "replay <capture>",
+	Short:   "Replays a capture of respondd datagrams to the outputs and databases",
+	Example: "yanic replay --config /etc/yanic.toml --speed 10 /var/lib/yanic/capture.gz",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+
+		err := allDatabase.Start(config.Database)
+		if err != nil {
+			panic(err)
+		}
+		defer allDatabase.Close()
+
+		nodes = runtime.NewNodes(&config.Nodes)
+
+		err = allOutput.Start(nodes, config.Nodes)
+		if err != nil {
+			panic(err)
+		}
+
+		// only parse the capture, do not request or record anything
+		respondConfig := config.Respondd
+		respondConfig.Interfaces = nil
+		respondConfig.Targets = nil
+		respondConfig.RecordPath = ""
+		collector := respond.NewCollector(allDatabase.Conn, nodes, &respondConfig)
+
+		count, err := replay(collector, args[0], replaySpeed)
+		collector.Close()
+		if err != nil {
+			log.Println("unable to read capture:", err)
+		}
+		log.Printf("replayed %d datagrams, %d nodes known", count, len(nodes.List))
+
+		allOutput.Close()
+		allOutput.Save(nodes)
+	},
+}
+
+// replay passes the datagrams of the capture file to the collector at the recorded times
+// relative to the first datagram, speed is the factor to the recorded time (0 for as fast as possible).
+// The nodes are updated with the time of the replay, so the last seen times and offline nodes
+// only match the recording with a speed of 1.
+func replay(collector *respond.Collector, path string, speed float64) (count int, err error) {
+	var first, started time.Time
+	err = respond.ReadCapture(path, func(res *respond.Response) error {
+		if speed > 0 {
+			if first.IsZero() {
+				first, started = res.Timestamp, time.Now()
+			}
+			at := started.Add(time.Duration(float64(res.Timestamp.Sub(first)) / speed))
+			if delay := time.Until(at); delay > 0 {
+				time.Sleep(delay)
+			}
+		}
+
+		collector.Replay(res)
+		count++
+		return nil
+	})
+	return
+}
+
+func init() {
+	RootCmd.AddCommand(replayCmd)
+	replayCmd.Flags().StringVarP(&configPath, "config", "c", "config.toml", "Path to configuration file")
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 1, "Speed relative to the recording, e.g. 10 for ten times faster (0 replays as fast as possible, without the recorded timing)")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/respond"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-replay")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture.gz")
+
+	compressed, err := ioutil.ReadFile("../respond/testdata/nodeinfo.flated")
+	assert.NoError(err)
+
+	recorder, err := respond.NewRecorder(path)
+	assert.NoError(err)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(recorder.Record(&respond.Response{
+			Address:   &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001},
+			Timestamp: start.Add(time.Duration(i) * 100 * time.Millisecond),
+			Raw:       compressed,
+		}))
+	}
+	assert.NoError(recorder.Close())
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	collector := respond.NewCollector(nil, nodes, &respond.Config{})
+
+	// ten times faster than recorded, the last datagram is 200ms after the first one
+	started := time.Now()
+	count, err := replay(collector, path, 10)
+	assert.NoError(err)
+	assert.Equal(3, count)
+	assert.True(time.Since(started) >= 20*time.Millisecond)
+
+	// as fast as possible, without the recorded 200ms
+	started = time.Now()
+	count, err = replay(collector, path, 0)
+	collector.Close()
+	assert.NoError(err)
+	assert.Equal(3, count)
+	assert.True(time.Since(started) < 200*time.Millisecond)
+	assert.Len(nodes.List, 1)
+
+	_, err = replay(collector, filepath.Join(dir, "missing.gz"), 0)
+	assert.Error(err)
+}
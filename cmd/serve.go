@@ -7,7 +7,9 @@ import (
 	"syscall"
 	"time"
 
+	allAlert "github.com/FreifunkBremen/yanic/alert/all"
 	allDatabase "github.com/FreifunkBremen/yanic/database/all"
+	allInput "github.com/FreifunkBremen/yanic/input/all"
 	allOutput "github.com/FreifunkBremen/yanic/output/all"
 	"github.com/FreifunkBremen/yanic/respond"
 	"github.com/FreifunkBremen/yanic/runtime"
@@ -22,6 +24,7 @@ var serveCmd = &cobra.Command{
 	Example: "yanic serve --config /etc/yanic.toml",
 	Run: func(cmd *cobra.Command, args []string) {
 		config := loadConfig()
+		runningConfig = config
 
 		err := allDatabase.Start(config.Database)
 		if err != nil {
@@ -31,6 +34,11 @@ var serveCmd = &cobra.Command{
 
 		nodes = runtime.NewNodes(&config.Nodes)
 		nodes.Start()
+		defer nodes.Close()
+
+		// store the transitions of the nodes
+		unsubscribe := nodes.Subscribe(allDatabase.Conn.InsertEvent)
+		defer unsubscribe()
 
 		err = allOutput.Start(nodes, config.Nodes)
 		if err != nil {
@@ -38,6 +46,12 @@ var serveCmd = &cobra.Command{
 		}
 		defer allOutput.Close()
 
+		err = allAlert.Start(nodes, config.Alerts, config.Nodes.SaveInterval.Duration)
+		if err != nil {
+			panic(err)
+		}
+		defer allAlert.Close()
+
 		if config.Webserver.Enable {
 			log.Println("starting webserver on", config.Webserver.Bind)
 			srv := webserver.New(config.Webserver.Bind, config.Webserver.Webroot)
@@ -54,16 +68,29 @@ var serveCmd = &cobra.Command{
 				time.Sleep(delay)
 			}
 
-			collector = respond.NewCollector(allDatabase.Conn, nodes, config.Respondd.SitesDomains(), config.Respondd.Interfaces)
+			collector = respond.NewCollector(allDatabase.Conn, nodes, &config.Respondd)
 			collector.Start(config.Respondd.CollectInterval.Duration)
 			defer collector.Close()
 		}
 
-		// Wait for INT/TERM
+		err = allInput.Start(nodes, allDatabase.Conn, config.Inputs)
+		if err != nil {
+			panic(err)
+		}
+		defer allInput.Close()
+
+		// Wait for INT/TERM, reload on HUP
 		sigs := make(chan os.Signal, 1)
-		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-		sig := <-sigs
-		log.Println("received", sig)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		for sig := range sigs {
+			log.Println("received", sig)
+			if sig != syscall.SIGHUP {
+				break
+			}
+			if err := Reload(); err != nil {
+				log.Println("reload failed, keeping the running configuration:", err)
+			}
+		}
 
 	},
 }
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/FreifunkBremen/yanic/simulator"
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulateConfig    simulator.Config
+	simulateListen    []string
+	simulateMulticast string
+	simulateTargets   string
+)
+
+// simulateCmd represents the simulate command
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Runs fake respondd responders of a generated mesh for load tests",
+	Long: `Runs fake respondd responders of a generated mesh for load tests.
+Every node answers unicast requests on its own UDP port,
+requests to the multicast group are answered with one response per online node of the mesh.`,
+	Example: `yanic simulate --nodes 5000 --listen "[::1]:1001" --loss 0.05 --churn 0.01`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sim := simulator.New(simulateConfig)
+		defer sim.Close()
+
+		var targets []*net.UDPAddr
+		for _, address := range simulateListen {
+			addresses, err := sim.Listen(address)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(addresses) > 0 {
+				log.Printf("simulating %d nodes on %s to %s", len(addresses), addresses[0], addresses[len(addresses)-1])
+			}
+			targets = append(targets, addresses...)
+		}
+		if simulateTargets != "" {
+			if err := writeSimulateTargets(simulateTargets, targets); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if simulateMulticast != "" {
+			group := &net.UDPAddr{IP: net.ParseIP("ff02::2:1001"), Port: 1001}
+			if err := sim.ListenMulticast(simulateMulticast, group); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("simulating %d nodes on %s%%%s", simulateConfig.Nodes, group, simulateMulticast)
+		}
+
+		// Wait for INT/TERM
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-sigs
+		log.Println("received", sig)
+	},
+}
+
+// writeSimulateTargets writes the [[respondd.targets]] of the simulated nodes for the configuration of a collector
+func writeSimulateTargets(path string, targets []*net.UDPAddr) error {
+	var buf bytes.Buffer
+	for _, target := range targets {
+		fmt.Fprintf(&buf, "[[respondd.targets]]\naddress = %q\nport    = %d\n\n", target.IP, target.Port)
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func init() {
+	RootCmd.AddCommand(simulateCmd)
+	simulateCmd.Flags().IntVar(&simulateConfig.Nodes, "nodes", 100, "Count of simulated nodes")
+	simulateCmd.Flags().IntVar(&simulateConfig.Gateways, "gateways", 2, "Count of nodes which are gateways")
+	simulateCmd.Flags().IntVar(&simulateConfig.Neighbours, "neighbours", 3, "Links per node")
+	simulateCmd.Flags().Float64Var(&simulateConfig.Loss, "loss", 0, "Probability to lose a response (0-1)")
+	simulateCmd.Flags().Float64Var(&simulateConfig.Churn, "churn", 0, "Probability of a node per request to go offline or to come back rebooted (0-1)")
+	simulateCmd.Flags().Float64Var(&simulateConfig.Growth, "growth", 1000, "Average traffic of a node in bytes per second")
+	simulateCmd.Flags().DurationVar(&simulateConfig.Spread, "spread", 0, "Spread the responses to a request over this period")
+	simulateCmd.Flags().Int64Var(&simulateConfig.Seed, "seed", 1, "Seed of the generated mesh")
+	simulateCmd.Flags().StringSliceVar(&simulateListen, "listen", []string{"[::1]:1001"}, "UDP address of the first node, the nodes answer requests on consecutive ports")
+	simulateCmd.Flags().StringVar(&simulateTargets, "targets", "", "Write the [[respondd.targets]] of the simulated nodes to this file")
+	simulateCmd.Flags().StringVar(&simulateMulticast, "multicast", "", "Interface to answer requests to the respondd multicast group on (e.g. a dummy interface)")
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/naoina/toml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteSimulateTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-simulate")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "targets.toml")
+
+	assert.NoError(writeSimulateTargets(path, []*net.UDPAddr{
+		{IP: net.ParseIP("::1"), Port: 1001},
+		{IP: net.ParseIP("::1"), Port: 1002},
+	}))
+
+	file, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	config := &Config{}
+	assert.NoError(toml.Unmarshal(file, config))
+	assert.Len(config.Respondd.Targets, 2)
+	assert.Equal("::1", config.Respondd.Targets[1].Address)
+	assert.Equal(1002, config.Respondd.Targets[1].Port)
+}
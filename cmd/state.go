@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/spf13/cobra"
+)
+
+// stateCmd represents the state command
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manages the state file of the nodes (state_path)",
+}
+
+// stateMigrateCmd represents the state migrate command
+var stateMigrateCmd = &cobra.Command{
+	Use:     "migrate",
+	Short:   "Migrates the state file to the current version, a backup is written with the suffix .bak",
+	Example: "yanic state migrate --config /etc/yanic.toml",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+
+		count, err := migrateState(&config.Nodes)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "unable to migrate the state:", err)
+			os.Exit(1)
+		}
+		log.Printf("migrated %d nodes to state version %d", count, runtime.StateVersion)
+	},
+}
+
+// migrateState loads the state with all migrations and writes it with the current version
+func migrateState(config *runtime.NodesConfig) (int, error) {
+	if config.StatePath == "" {
+		return 0, fmt.Errorf("no state_path configured")
+	}
+
+	raw, err := ioutil.ReadFile(config.StatePath)
+	if err != nil {
+		return 0, err
+	}
+	backup := config.StatePath + ".bak"
+	if err = ioutil.WriteFile(backup, raw, 0644); err != nil {
+		return 0, err
+	}
+	log.Println("saved a backup of the state to", backup)
+
+	// on an error the nodes are not closed, which would save them without the stored ones
+	nodes, err := runtime.LoadNodes(config)
+	if err != nil {
+		return 0, err
+	}
+	defer nodes.Close()
+
+	if err = nodes.Rewrite(); err != nil {
+		return 0, err
+	}
+	return len(nodes.List), nil
+}
+
+func init() {
+	stateCmd.AddCommand(stateMigrateCmd)
+	RootCmd.AddCommand(stateCmd)
+	stateMigrateCmd.Flags().StringVarP(&configPath, "config", "c", "config.toml", "Path to configuration file")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestMigrateState(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-state")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := &runtime.NodesConfig{}
+	_, err = migrateState(config)
+	assert.Error(err)
+
+	config.StatePath = filepath.Join(dir, "state.json")
+	_, err = migrateState(config)
+	assert.Error(err)
+
+	// a state file without version
+	raw := []byte(`{"nodes": {
+		"f81a67a601ea": {"firstseen": "2017-03-10T12:12:01+0000"},
+		"f81a67a601eb": {"firstseen": "2016-03-10T12:12:01+0000"}
+	}}`)
+	assert.NoError(ioutil.WriteFile(config.StatePath, raw, 0644))
+
+	count, err := migrateState(config)
+	assert.NoError(err)
+	assert.Equal(2, count)
+
+	backup, err := ioutil.ReadFile(config.StatePath + ".bak")
+	assert.NoError(err)
+	assert.Equal(raw, backup)
+
+	migrated, err := ioutil.ReadFile(config.StatePath)
+	assert.NoError(err)
+	assert.Contains(string(migrated), `"version":2`)
+	assert.Contains(string(migrated), `"firstseen":"2017-03-10T12:12:01+0000"`)
+}
@@ -1,8 +1,239 @@
 package data
 
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Sections of a respondd request which are decoded into structs
+const (
+	SectionNodeInfo   = "nodeinfo"
+	SectionStatistics = "statistics"
+	SectionNeighbours = "neighbours"
+)
+
 // ResponseData struct
 type ResponseData struct {
 	Neighbours *Neighbours `json:"neighbours"`
 	NodeInfo   *NodeInfo   `json:"nodeinfo"`
 	Statistics *Statistics `json:"statistics"`
+
+	// CustomFields keeps everything yanic does not know as raw JSON.
+	// Unknown sections are stored with their whole content,
+	// known sections with an object of only their unknown fields (including nested ones).
+	CustomFields map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the known sections and keeps the rest in CustomFields
+func (res *ResponseData) UnmarshalJSON(b []byte) error {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(b, &sections); err != nil {
+		return err
+	}
+
+	for name, raw := range sections {
+		if string(raw) == "null" {
+			continue
+		}
+
+		var section interface{}
+		switch name {
+		case SectionNodeInfo:
+			res.NodeInfo = &NodeInfo{}
+			section = res.NodeInfo
+		case SectionStatistics:
+			res.Statistics = &Statistics{}
+			section = res.Statistics
+		case SectionNeighbours:
+			res.Neighbours = &Neighbours{}
+			section = res.Neighbours
+		default:
+			res.setCustomField(name, raw)
+			continue
+		}
+
+		if err := json.Unmarshal(raw, section); err != nil {
+			return err
+		}
+		if unknown := unknownFields(raw, reflect.TypeOf(section)); unknown != nil {
+			res.setCustomField(name, unknown)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the known sections together with the CustomFields
+func (res ResponseData) MarshalJSON() ([]byte, error) {
+	sections := make(map[string]interface{}, len(res.CustomFields)+3)
+	for name, raw := range res.CustomFields {
+		sections[name] = raw
+	}
+
+	var err error
+	if res.NodeInfo != nil {
+		if sections[SectionNodeInfo], err = withCustomFields(res.NodeInfo, res.CustomFields[SectionNodeInfo]); err != nil {
+			return nil, err
+		}
+	}
+	if res.Statistics != nil {
+		if sections[SectionStatistics], err = withCustomFields(res.Statistics, res.CustomFields[SectionStatistics]); err != nil {
+			return nil, err
+		}
+	}
+	if res.Neighbours != nil {
+		if sections[SectionNeighbours], err = withCustomFields(res.Neighbours, res.CustomFields[SectionNeighbours]); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(sections)
+}
+
+// NodeID returns the NodeID of the first section which contains one
+func (res *ResponseData) NodeID() string {
+	if val := res.NodeInfo; val != nil {
+		return val.NodeID
+	} else if val := res.Neighbours; val != nil {
+		return val.NodeID
+	} else if val := res.Statistics; val != nil {
+		return val.NodeID
+	}
+	return ""
+}
+
+// DropMismatches sets the sections of other nodes to nil and returns their count
+func (res *ResponseData) DropMismatches(nodeID string) (dropped int) {
+	if res.Statistics != nil && res.Statistics.NodeID != nodeID {
+		res.Statistics = nil
+		dropped++
+	}
+	if res.Neighbours != nil && res.Neighbours.NodeID != nodeID {
+		res.Neighbours = nil
+		dropped++
+	}
+	if res.NodeInfo != nil && res.NodeInfo.NodeID != nodeID {
+		res.NodeInfo = nil
+		dropped++
+	}
+	return
+}
+
+func (res *ResponseData) setCustomField(name string, raw json.RawMessage) {
+	if res.CustomFields == nil {
+		res.CustomFields = make(map[string]json.RawMessage)
+	}
+	res.CustomFields[name] = raw
+}
+
+// unknownFields returns an object of all fields in raw, which are not part of the type t,
+// nested objects of known fields are searched recursively and only their unknown fields are kept
+func unknownFields(raw json.RawMessage, t reflect.Type) json.RawMessage {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	var known map[string]reflect.Type
+	if t.Kind() == reflect.Struct {
+		known = knownFields(t)
+	}
+	for name, value := range fields {
+		var fieldType reflect.Type
+		if known != nil {
+			var ok bool
+			if fieldType, ok = known[strings.ToLower(name)]; !ok {
+				continue
+			}
+		} else {
+			// every key of a map is known, its values might contain unknown fields
+			fieldType = t.Elem()
+		}
+		if nested := unknownFields(value, fieldType); nested != nil {
+			fields[name] = nested
+		} else {
+			delete(fields, name)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	unknown, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	return unknown
+}
+
+// withCustomFields adds the unknown fields back to the encoded section
+func withCustomFields(section interface{}, custom json.RawMessage) (interface{}, error) {
+	if custom == nil {
+		return section, nil
+	}
+
+	encoded, err := json.Marshal(section)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(mergeFields(encoded, custom)), nil
+}
+
+// mergeFields adds the fields of extra, which are missing in encoded, recursively into its objects
+func mergeFields(encoded, extra json.RawMessage) json.RawMessage {
+	var fields, extraFields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return encoded
+	}
+	if fields == nil {
+		// omitted by yanic (null)
+		return extra
+	}
+	if err := json.Unmarshal(extra, &extraFields); err != nil {
+		return encoded
+	}
+	for name, value := range extraFields {
+		if known, ok := fields[name]; ok {
+			fields[name] = mergeFields(known, value)
+		} else {
+			fields[name] = value
+		}
+	}
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return encoded
+	}
+	return merged
+}
+
+var knownFieldsCache sync.Map
+
+// knownFields returns the types of all fields of the given struct type by their lowercased json names
+func knownFields(t reflect.Type) map[string]reflect.Type {
+	if cached, ok := knownFieldsCache.Load(t); ok {
+		return cached.(map[string]reflect.Type)
+	}
+
+	known := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		known[strings.ToLower(name)] = field.Type
+	}
+
+	knownFieldsCache.Store(t, known)
+	return known
 }
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseDataCustomFields(t *testing.T) {
+	assert := assert.New(t)
+
+	res := &ResponseData{}
+	err := json.Unmarshal([]byte(`{
+		"nodeinfo": {"node_id": "f81a67a601ea", "hostname": "a", "pages": {"status": 1}},
+		"statistics": null,
+		"wireless": {"airtime": [1, 2]}
+	}`), res)
+	assert.NoError(err)
+
+	assert.NotNil(res.NodeInfo)
+	assert.Equal("f81a67a601ea", res.NodeInfo.NodeID)
+	assert.Nil(res.Statistics)
+	assert.Nil(res.Neighbours)
+
+	assert.Len(res.CustomFields, 2)
+	assert.JSONEq(`{"pages": {"status": 1}}`, string(res.CustomFields["nodeinfo"]))
+	assert.JSONEq(`{"airtime": [1, 2]}`, string(res.CustomFields["wireless"]))
+
+	// encode them back to the original position
+	encoded, err := json.Marshal(res)
+	assert.NoError(err)
+
+	var sections map[string]map[string]interface{}
+	assert.NoError(json.Unmarshal(encoded, &sections))
+	assert.Len(sections, 2)
+	assert.Equal("a", sections["nodeinfo"]["hostname"])
+	assert.Contains(sections["nodeinfo"], "pages")
+	assert.Contains(sections["wireless"], "airtime")
+
+	// no custom fields
+	res = &ResponseData{}
+	assert.NoError(json.Unmarshal([]byte(`{"neighbours": {"node_id": "f81a67a601ea"}}`), res))
+	assert.Nil(res.CustomFields)
+	assert.Equal("f81a67a601ea", res.Neighbours.NodeID)
+
+	// invalid
+	assert.Error(json.Unmarshal([]byte(`{"nodeinfo": 5}`), &ResponseData{}))
+	assert.Error(json.Unmarshal([]byte(`[]`), &ResponseData{}))
+}
+
+func TestResponseDataNestedCustomFields(t *testing.T) {
+	assert := assert.New(t)
+
+	res := &ResponseData{}
+	err := json.Unmarshal([]byte(`{
+		"nodeinfo": {
+			"node_id": "f81a67a601ea",
+			"software": {"firmware": {"base": "gluon", "build": 42}, "custom": "x"},
+			"network": {"mesh": {"bat0": {"interfaces": {"wireless": ["a"]}, "extra": true}}}
+		},
+		"statistics": {"node_id": "f81a67a601ea", "traffic": {"rx": {"bytes": 1}, "vpn": {"bytes": 2}}}
+	}`), res)
+	assert.NoError(err)
+	assert.Equal("gluon", res.NodeInfo.Software.Firmware.Base)
+	assert.Equal(float64(1), res.Statistics.Traffic.Rx.Bytes)
+
+	assert.JSONEq(`{
+		"software": {"firmware": {"build": 42}, "custom": "x"},
+		"network": {"mesh": {"bat0": {"extra": true}}}
+	}`, string(res.CustomFields["nodeinfo"]))
+	assert.JSONEq(`{"traffic": {"vpn": {"bytes": 2}}}`, string(res.CustomFields["statistics"]))
+
+	// encode them back to the original position
+	encoded, err := json.Marshal(res)
+	assert.NoError(err)
+
+	var sections map[string]map[string]interface{}
+	assert.NoError(json.Unmarshal(encoded, &sections))
+	software := sections["nodeinfo"]["software"].(map[string]interface{})
+	assert.Equal("x", software["custom"])
+	assert.Equal(map[string]interface{}{"base": "gluon", "build": float64(42)}, software["firmware"])
+	traffic := sections["statistics"]["traffic"].(map[string]interface{})
+	assert.Contains(traffic, "rx")
+	assert.Contains(traffic, "vpn")
+}
+
+func TestResponseDataNodeID(t *testing.T) {
+	assert := assert.New(t)
+
+	res := &ResponseData{}
+	assert.Equal("", res.NodeID())
+
+	res = &ResponseData{
+		Statistics: &Statistics{NodeID: "f81a67a601ea"},
+		Neighbours: &Neighbours{NodeID: "f81a67a601eb"},
+	}
+	assert.Equal("f81a67a601eb", res.NodeID())
+
+	res.NodeInfo = &NodeInfo{NodeID: "f81a67a601ea"}
+	assert.Equal("f81a67a601ea", res.NodeID())
+	assert.Equal(1, res.DropMismatches("f81a67a601ea"))
+	assert.Nil(res.Neighbours)
+	assert.NotNil(res.Statistics)
+	assert.NotNil(res.NodeInfo)
+}
@@ -3,6 +3,7 @@ package all
 import (
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/FreifunkBremen/yanic/database"
@@ -12,10 +13,19 @@ import (
 type Connection struct {
 	database.Connection
 	list []database.Connection
+	lock sync.RWMutex
 }
 
+// Connect opens the enabled connections of all database types,
+// on an error the already opened connections are closed
 func Connect(allConnection map[string]interface{}) (database.Connection, error) {
 	var list []database.Connection
+	fail := func(err error) (database.Connection, error) {
+		for _, item := range list {
+			item.Close()
+		}
+		return nil, err
+	}
 	for dbType, conn := range database.Adapters {
 		configForType := allConnection[dbType]
 		if configForType == nil {
@@ -24,20 +34,20 @@ func Connect(allConnection map[string]interface{}) (database.Connection, error)
 		}
 		dbConfigs, ok := configForType.([]interface{})
 		if !ok {
-			return nil, fmt.Errorf("the database type '%s' has the wrong format", dbType)
+			return fail(fmt.Errorf("the database type '%s' has the wrong format", dbType))
 		}
 
 		for _, dbConfig := range dbConfigs {
 			config, ok := dbConfig.(map[string]interface{})
 			if !ok {
-				return nil, fmt.Errorf("the database type '%s' has the wrong format", dbType)
+				return fail(fmt.Errorf("the database type '%s' has the wrong format", dbType))
 			}
 			if c, ok := config["enable"].(bool); ok && !c {
 				continue
 			}
 			connected, err := conn(config)
 			if err != nil {
-				return nil, err
+				return fail(err)
 			}
 			if connected == nil {
 				continue
@@ -49,31 +59,69 @@ func Connect(allConnection map[string]interface{}) (database.Connection, error)
 }
 
 func (conn *Connection) InsertNode(node *runtime.Node) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
 	for _, item := range conn.list {
 		item.InsertNode(node)
 	}
 }
 
 func (conn *Connection) InsertLink(link *runtime.Link, time time.Time) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
 	for _, item := range conn.list {
 		item.InsertLink(link, time)
 	}
 }
 
 func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string, domain string) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
 	for _, item := range conn.list {
 		item.InsertGlobals(stats, time, site, domain)
 	}
 }
 
+func (conn *Connection) InsertCollectorStats(stats *runtime.CollectorStats, time time.Time) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
+	for _, item := range conn.list {
+		item.InsertCollectorStats(stats, time)
+	}
+}
+
+func (conn *Connection) InsertEvent(event *runtime.Event) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
+	for _, item := range conn.list {
+		item.InsertEvent(event)
+	}
+}
+
 func (conn *Connection) PruneNodes(deleteAfter time.Duration) {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
 	for _, item := range conn.list {
 		item.PruneNodes(deleteAfter)
 	}
 }
 
 func (conn *Connection) Close() {
+	conn.lock.RLock()
+	defer conn.lock.RUnlock()
 	for _, item := range conn.list {
 		item.Close()
 	}
 }
+
+// replace swaps the connections by the ones of other and closes the old ones
+func (conn *Connection) replace(other *Connection) {
+	conn.lock.Lock()
+	old := conn.list
+	conn.list = other.list
+	conn.lock.Unlock()
+
+	for _, item := range old {
+		item.Close()
+	}
+}
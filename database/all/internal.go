@@ -16,10 +16,23 @@ func Start(config database.Config) (err error) {
 	if err != nil {
 		return
 	}
+	start(config)
+	return
+}
+
+// Reload replaces the connections of Conn by the given ones (created by Connect),
+// closes the old ones and restarts the prune worker with the new configuration
+func Reload(connected database.Connection, config database.Config) {
+	close(quit)
+	wg.Wait()
+	Conn.(*Connection).replace(connected.(*Connection))
+	start(config)
+}
+
+func start(config database.Config) {
 	quit = make(chan struct{})
 	wg.Add(1)
 	go deleteWorker(config.DeleteInterval.Duration, config.DeleteAfter.Duration)
-	return
 }
 
 func Close() {
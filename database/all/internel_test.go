@@ -73,3 +73,63 @@ func TestStart(t *testing.T) {
 	})
 	assert.Error(err)
 }
+
+type testConn struct {
+	database.Connection
+	closed bool
+}
+
+func (c *testConn) Close() {
+	c.closed = true
+}
+
+func TestReload(t *testing.T) {
+	assert := assert.New(t)
+
+	config := database.Config{
+		DeleteInterval: duration.Duration{Duration: time.Hour},
+	}
+	assert.NoError(Start(config))
+
+	oldConn := &testConn{}
+	Conn.(*Connection).list = []database.Connection{oldConn}
+	newConn := &testConn{}
+	conn := Conn
+
+	Reload(&Connection{list: []database.Connection{newConn}}, config)
+	assert.True(oldConn.closed)
+	assert.False(newConn.closed)
+	assert.Equal(conn, Conn, "collectors keep their reference to Conn")
+
+	Close()
+	assert.True(newConn.closed)
+}
+
+func TestConnectFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	var opened []*testConn
+	database.RegisterAdapter("f", func(config map[string]interface{}) (database.Connection, error) {
+		if config["path"] == "fail" {
+			return nil, errors.New("blub")
+		}
+		conn := &testConn{}
+		opened = append(opened, conn)
+		return conn, nil
+	})
+	defer delete(database.Adapters, "f")
+
+	// the opened connections are closed
+	_, err := Connect(map[string]interface{}{
+		"f": []interface{}{
+			map[string]interface{}{"path": "f1"},
+			map[string]interface{}{"path": "f2"},
+			map[string]interface{}{"path": "fail"},
+		},
+	})
+	assert.Error(err)
+	assert.Len(opened, 2)
+	for _, conn := range opened {
+		assert.True(conn.closed)
+	}
+}
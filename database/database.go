@@ -17,6 +17,12 @@ type Connection interface {
 	// InsertGlobals stores global statistics
 	InsertGlobals(*runtime.GlobalStats, time.Time, string, string)
 
+	// InsertCollectorStats stores the self-metrics of the collector
+	InsertCollectorStats(*runtime.CollectorStats, time.Time)
+
+	// InsertEvent stores a transition of a node (see Nodes.Subscribe)
+	InsertEvent(*runtime.Event)
+
 	// PruneNodes prunes historical per-node data
 	PruneNodes(deleteAfter time.Duration)
 
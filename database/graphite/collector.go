@@ -0,0 +1,50 @@
+package graphite
+
+import (
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/fgrosse/graphigo"
+)
+
+func (c *Connection) InsertCollectorStats(stats *runtime.CollectorStats, time time.Time) {
+	fields := CollectorStatsFields(MeasurementCollector, stats)
+	for ifname, ifaceStats := range stats.Interfaces {
+		prefix := MeasurementCollector + ".interface." + replaceInvalidChars(ifname)
+		up := 0
+		if ifaceStats.Up {
+			up = 1
+		}
+		fields = append(fields,
+			graphigo.Metric{Name: prefix + ".received", Value: ifaceStats.Received},
+			graphigo.Metric{Name: prefix + ".requests_sent", Value: ifaceStats.RequestsSent},
+			graphigo.Metric{Name: prefix + ".up", Value: up},
+			graphigo.Metric{Name: prefix + ".binds", Value: ifaceStats.Binds},
+		)
+	}
+	for i := range fields {
+		fields[i].Timestamp = time
+	}
+	c.addPoint(fields)
+}
+
+func CollectorStatsFields(name string, stats *runtime.CollectorStats) []graphigo.Metric {
+	return []graphigo.Metric{
+		{Name: name + ".decode_errors", Value: stats.DecodeErrors},
+		{Name: name + ".invalid_node_ids", Value: stats.InvalidNodeIDs},
+		{Name: name + ".node_id_mismatches", Value: stats.NodeIDMismatches},
+		{Name: name + ".responses", Value: stats.Responses},
+		{Name: name + ".duplicates", Value: stats.Duplicates},
+		{Name: name + ".rejected", Value: stats.Rejected},
+		{Name: name + ".multicasts_sent", Value: stats.MulticastsSent},
+		{Name: name + ".unicasts_sent", Value: stats.UnicastsSent},
+		{Name: name + ".queue.length", Value: stats.QueueLength},
+		{Name: name + ".queue.capacity", Value: stats.QueueCapacity},
+		{Name: name + ".queue.dropped", Value: stats.QueueDropped},
+		{Name: name + ".database.queue", Value: stats.DatabaseQueueLength},
+		{Name: name + ".database.dropped", Value: stats.DatabaseDropped},
+		{Name: name + ".round.responses", Value: stats.RoundResponses},
+		{Name: name + ".round.latency_avg", Value: stats.RoundLatencyAvg.Seconds()},
+		{Name: name + ".round.latency_max", Value: stats.RoundLatencyMax.Seconds()},
+	}
+}
@@ -11,6 +11,7 @@ import (
 const (
 	MeasurementNode               = "node"        // Measurement for per-node statistics
 	MeasurementGlobal             = "global"      // Measurement for summarized global statistics
+	MeasurementCollector          = "yanic"       // Measurement for self-metrics of the collector
 	CounterMeasurementFirmware    = "firmware"    // Measurement for firmware statistics
 	CounterMeasurementModel       = "model"       // Measurement for model statistics
 	CounterMeasurementAutoupdater = "autoupdater" // Measurement for autoupdater
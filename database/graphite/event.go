@@ -0,0 +1,9 @@
+package graphite
+
+import (
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// InsertEvent is not supported, graphite stores only numeric series
+func (c *Connection) InsertEvent(event *runtime.Event) {
+}
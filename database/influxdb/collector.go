@@ -0,0 +1,51 @@
+package influxdb
+
+import (
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+	"github.com/influxdata/influxdb/models"
+)
+
+// InsertCollectorStats implementation of database
+func (conn *Connection) InsertCollectorStats(stats *runtime.CollectorStats, time time.Time) {
+	conn.addPoint(MeasurementCollector, models.Tags{}, CollectorStatsFields(stats), time)
+
+	for ifname, ifaceStats := range stats.Interfaces {
+		conn.addPoint(
+			MeasurementCollector,
+			models.Tags{
+				models.Tag{Key: []byte("ifname"), Value: []byte(ifname)},
+			},
+			models.Fields{
+				"received":      ifaceStats.Received,
+				"requests_sent": ifaceStats.RequestsSent,
+				"up":            ifaceStats.Up,
+				"binds":         ifaceStats.Binds,
+			},
+			time,
+		)
+	}
+}
+
+// CollectorStatsFields returns fields for InfluxDB
+func CollectorStatsFields(stats *runtime.CollectorStats) map[string]interface{} {
+	return map[string]interface{}{
+		"decode_errors":      stats.DecodeErrors,
+		"invalid_node_ids":   stats.InvalidNodeIDs,
+		"node_id_mismatches": stats.NodeIDMismatches,
+		"responses":          stats.Responses,
+		"duplicates":         stats.Duplicates,
+		"rejected":           stats.Rejected,
+		"multicasts_sent":    stats.MulticastsSent,
+		"unicasts_sent":      stats.UnicastsSent,
+		"queue.length":       stats.QueueLength,
+		"queue.capacity":     stats.QueueCapacity,
+		"queue.dropped":      stats.QueueDropped,
+		"database.queue":     stats.DatabaseQueueLength,
+		"database.dropped":   stats.DatabaseDropped,
+		"round.responses":    stats.RoundResponses,
+		"round.latency_avg":  stats.RoundLatencyAvg.Seconds(),
+		"round.latency_max":  stats.RoundLatencyMax.Seconds(),
+	}
+}
@@ -0,0 +1,45 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/client/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestCollectorStats(t *testing.T) {
+	assert := assert.New(t)
+
+	stats := &runtime.CollectorStats{
+		Interfaces: map[string]*runtime.InterfaceStats{
+			"br-ffhb": {Received: 10, RequestsSent: 2},
+		},
+		Responses:       9,
+		Duplicates:      3,
+		DecodeErrors:    1,
+		RoundLatencyAvg: time.Second / 2,
+	}
+
+	fields := CollectorStatsFields(stats)
+	assert.EqualValues(9, fields["responses"])
+	assert.EqualValues(3, fields["duplicates"])
+	assert.EqualValues(1, fields["decode_errors"])
+	assert.Equal(0.5, fields["round.latency_avg"])
+
+	conn := &Connection{
+		points: make(chan *client.Point, 2),
+	}
+	conn.InsertCollectorStats(stats, time.Now())
+	close(conn.points)
+
+	var points []*client.Point
+	for point := range conn.points {
+		assert.Equal(MeasurementCollector, point.Name())
+		points = append(points, point)
+	}
+	assert.Len(points, 2)
+	assert.Equal("br-ffhb", points[1].Tags()["ifname"])
+}
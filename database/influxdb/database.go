@@ -16,6 +16,8 @@ const (
 	MeasurementNode               = "node"        // Measurement for per-node statistics
 	MeasurementDHCP               = "dhcp"        // Measurement for DHCP server statistics
 	MeasurementGlobal             = "global"      // Measurement for summarized global statistics
+	MeasurementCollector          = "yanic"       // Measurement for self-metrics of the collector
+	MeasurementEvent              = "event"       // Measurement for transitions of the nodes
 	CounterMeasurementFirmware    = "firmware"    // Measurement for firmware statistics
 	CounterMeasurementModel       = "model"       // Measurement for model statistics
 	CounterMeasurementAutoupdater = "autoupdater" // Measurement for autoupdater
@@ -0,0 +1,22 @@
+package influxdb
+
+import (
+	models "github.com/influxdata/influxdb/models"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// InsertEvent stores a transition of a node (e.g. for annotations in grafana)
+func (conn *Connection) InsertEvent(event *runtime.Event) {
+	tags := models.Tags{}
+	tags.SetString("nodeid", event.NodeID)
+	tags.SetString("type", string(event.Type))
+	if node := event.Node; node != nil && node.Nodeinfo != nil {
+		tags.SetString("hostname", node.Nodeinfo.Hostname)
+	}
+
+	conn.addPoint(MeasurementEvent, tags, models.Fields{
+		"old": event.Old,
+		"new": event.New,
+	}, event.Time.GetTime())
+}
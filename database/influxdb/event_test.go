@@ -0,0 +1,40 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb/client/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestInsertEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	conn := &Connection{
+		points: make(chan *client.Point, 1),
+	}
+	conn.InsertEvent(&runtime.Event{
+		Type:   runtime.EventHostnameChanged,
+		NodeID: "f81a67a601ea",
+		Time:   jsontime.Now(),
+		Old:    "old",
+		New:    "new",
+		Node:   &runtime.Node{Nodeinfo: &data.NodeInfo{Hostname: "new"}},
+	})
+	close(conn.points)
+
+	point := <-conn.points
+	assert.Equal(MeasurementEvent, point.Name())
+	tags := point.Tags()
+	assert.Equal("f81a67a601ea", tags["nodeid"])
+	assert.Equal("hostname_changed", tags["type"])
+	assert.Equal("new", tags["hostname"])
+	fields, err := point.Fields()
+	assert.NoError(err)
+	assert.Equal("old", fields["old"])
+	assert.Equal("new", fields["new"])
+}
@@ -13,7 +13,7 @@ import (
 
 // PruneNodes prunes historical per-node data
 func (conn *Connection) PruneNodes(deleteAfter time.Duration) {
-	for _, measurement := range []string{MeasurementNode, MeasurementLink} {
+	for _, measurement := range []string{MeasurementNode, MeasurementLink, MeasurementEvent} {
 		query := fmt.Sprintf("delete from %s where time < now() - %ds", measurement, deleteAfter/time.Second)
 		conn.client.Query(client.NewQuery(query, conn.config.Database(), "m"))
 	}
@@ -29,7 +29,11 @@ func (conn *Connection) InsertNode(node *runtime.Node) {
 		return
 	}
 
+	// custom tags of the override, the tags of yanic take precedence
 	tags := models.Tags{}
+	for key, value := range node.Tags() {
+		tags.SetString(key, value)
+	}
 	tags.SetString("nodeid", stats.NodeID)
 
 	fields := models.Fields{
@@ -78,6 +78,9 @@ func TestToInflux(t *testing.T) {
 				},
 			},
 		},
+		Override: &runtime.Override{
+			Tags: map[string]string{"room": "attic", "site": "other"},
+		},
 		Neighbours: &data.Neighbours{
 			NodeID: "deadbeef",
 			Batadv: map[string]data.BatadvNeighbours{
@@ -141,6 +144,7 @@ func TestToInflux(t *testing.T) {
 	assert.EqualValues("nobody", tags["owner"])
 	assert.EqualValues("testing", tags["autoupdater"])
 	assert.EqualValues("ffhb", tags["site"])
+	assert.EqualValues("attic", tags["room"])
 	assert.EqualValues("city", tags["domain"])
 	assert.EqualValues(0.5, fields["load"])
 	assert.EqualValues(0, fields["neighbours.lldp"])
@@ -54,6 +54,14 @@ func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time
 	conn.log("InsertGlobals: [", time.String(), "] site: ", site, " domain: ", domain, ", nodes: ", stats.Nodes, ", clients: ", stats.Clients, " models: ", len(stats.Models))
 }
 
+func (conn *Connection) InsertCollectorStats(stats *runtime.CollectorStats, time time.Time) {
+	conn.log("InsertCollectorStats: [", time.String(), "] responses: ", stats.Responses, ", decode errors: ", stats.DecodeErrors, ", queue: ", stats.QueueLength, "/", stats.QueueCapacity, ", latency: ", stats.RoundLatencyAvg)
+}
+
+func (conn *Connection) InsertEvent(event *runtime.Event) {
+	conn.log("InsertEvent: [", event.NodeID, "] ", event.Type, ": ", event.Old, " -> ", event.New)
+}
+
 func (conn *Connection) PruneNodes(deleteAfter time.Duration) {
 	conn.log("PruneNodes")
 }
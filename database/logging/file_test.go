@@ -47,6 +47,11 @@ func TestStart(t *testing.T) {
 	dat, _ = ioutil.ReadFile(path)
 	assert.Contains(string(dat), "InsertGlobals")
 
+	assert.NotContains(string(dat), "InsertCollectorStats")
+	conn.InsertCollectorStats(&runtime.CollectorStats{}, time.Now())
+	dat, _ = ioutil.ReadFile(path)
+	assert.Contains(string(dat), "InsertCollectorStats")
+
 	assert.NotContains(string(dat), "PruneNodes")
 	conn.PruneNodes(time.Second)
 	dat, _ = ioutil.ReadFile(path)
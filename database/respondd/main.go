@@ -4,22 +4,28 @@ package respondd
  * This database type is for injecting into another yanic instance.
  */
 import (
-	"bufio"
+	"bytes"
 	"compress/flate"
 	"encoding/json"
 	"log"
 	"net"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/FreifunkBremen/yanic/data"
 	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/lib/push"
 	"github.com/FreifunkBremen/yanic/runtime"
 )
 
 type Connection struct {
 	database.Connection
 	config Config
-	conn   net.Conn
+	auth   push.Auth
+	sync.Mutex
+	conn net.Conn
 }
 
 type Config map[string]interface{}
@@ -32,6 +38,36 @@ func (c Config) Address() string {
 	return c["address"].(string)
 }
 
+// Key to authenticate at the receiver of another yanic instance, without a key the data is sent unframed
+func (c Config) Key() string {
+	if key, ok := c["key"].(string); ok {
+		return key
+	}
+	return ""
+}
+
+// Auth is the method of authentication (hmac or psk)
+func (c Config) Auth() string {
+	if auth, ok := c["auth"].(string); ok {
+		return auth
+	}
+	return ""
+}
+
+// Name of this instance at the receiver (default: hostname)
+func (c Config) Name() string {
+	if name, ok := c["name"].(string); ok && name != "" {
+		return name
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+// stream is true for connection types with a stream of bytes, which need a length of every frame
+func (c Config) stream() bool {
+	return strings.HasPrefix(c.Type(), "tcp") || c.Type() == "unix"
+}
+
 func init() {
 	database.RegisterAdapter("respondd", Connect)
 }
@@ -40,29 +76,38 @@ func Connect(configuration map[string]interface{}) (database.Connection, error)
 	var config Config
 	config = configuration
 
+	auth, err := push.ParseAuth(config.Auth())
+	if err != nil {
+		return nil, err
+	}
+
 	conn, err := net.Dial(config.Type(), config.Address())
 	if err != nil {
 		return nil, err
 	}
 
-	return &Connection{conn: conn, config: config}, nil
+	return &Connection{conn: conn, config: config, auth: auth}, nil
 }
 
 func (conn *Connection) InsertNode(node *runtime.Node) {
+	// send the nodeinfo as reported by the node, without the local override
+	nodeinfo := node.Nodeinfo
+	if node.Reported != nil {
+		nodeinfo = node.Reported
+	}
 	res := &data.ResponseData{
-		NodeInfo:   node.Nodeinfo,
-		Statistics: node.Statistics,
-		Neighbours: node.Neighbours,
+		NodeInfo:     nodeinfo,
+		Statistics:   node.Statistics,
+		Neighbours:   node.Neighbours,
+		CustomFields: node.CustomFields,
 	}
 
-	writer := bufio.NewWriterSize(conn.conn, 8192)
-
-	flater, err := flate.NewWriter(writer, flate.BestCompression)
+	var buf bytes.Buffer
+	flater, err := flate.NewWriter(&buf, flate.BestCompression)
 	if err != nil {
 		log.Printf("[database-yanic] could not create flater: %s", err)
 		return
 	}
-	defer flater.Close()
 	err = json.NewEncoder(flater).Encode(res)
 	if err != nil {
 		nodeid := "unknown"
@@ -72,25 +117,71 @@ func (conn *Connection) InsertNode(node *runtime.Node) {
 		log.Printf("[database-yanic] could not encode %s node: %s", nodeid, err)
 		return
 	}
-	err = flater.Flush()
+	err = flater.Close()
 	if err != nil {
 		log.Printf("[database-yanic] could not compress: %s", err)
+		return
 	}
-	err = writer.Flush()
+
+	err = conn.send(buf.Bytes())
 	if err != nil {
 		log.Printf("[database-yanic] could not send: %s", err)
 	}
 }
 
+// send writes the deflated data, framed if a key is given,
+// a broken connection is dialed again once
+func (conn *Connection) send(payload []byte) error {
+	if key := conn.config.Key(); key != "" {
+		frame, err := push.Encode(conn.config.Name(), conn.auth, []byte(key), payload, time.Now())
+		if err != nil {
+			return err
+		}
+		payload = frame
+	}
+
+	conn.Lock()
+	defer conn.Unlock()
+
+	err := conn.write(payload)
+	if err == nil || !conn.config.stream() {
+		return err
+	}
+	log.Printf("[database-yanic] reconnecting after: %s", err)
+	redialed, err := net.Dial(conn.config.Type(), conn.config.Address())
+	if err != nil {
+		return err
+	}
+	conn.conn.Close()
+	conn.conn = redialed
+	return conn.write(payload)
+}
+
+func (conn *Connection) write(payload []byte) error {
+	if conn.config.Key() != "" && conn.config.stream() {
+		return push.WriteFrame(conn.conn, payload)
+	}
+	_, err := conn.conn.Write(payload)
+	return err
+}
+
 func (conn *Connection) InsertLink(link *runtime.Link, time time.Time) {
 }
 
 func (conn *Connection) InsertGlobals(stats *runtime.GlobalStats, time time.Time, site string, domain string) {
 }
 
+func (conn *Connection) InsertCollectorStats(stats *runtime.CollectorStats, time time.Time) {
+}
+
+func (conn *Connection) InsertEvent(event *runtime.Event) {
+}
+
 func (conn *Connection) PruneNodes(deleteAfter time.Duration) {
 }
 
 func (conn *Connection) Close() {
+	conn.Lock()
+	defer conn.Unlock()
 	conn.conn.Close()
 }
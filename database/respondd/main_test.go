@@ -1,7 +1,12 @@
 package respondd
 
 import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/FreifunkBremen/yanic/data"
 	"github.com/FreifunkBremen/yanic/runtime"
@@ -47,3 +52,34 @@ func TestStart(t *testing.T) {
 	conn.Close()
 
 }
+
+func TestInsertNodeReported(t *testing.T) {
+	assert := assert.New(t)
+
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer listener.Close()
+
+	conn, err := Connect(map[string]interface{}{
+		"type":    "udp",
+		"address": listener.LocalAddr().String(),
+	})
+	assert.NoError(err)
+	defer conn.Close()
+
+	// the override is not sent
+	conn.InsertNode(&runtime.Node{
+		Nodeinfo: &data.NodeInfo{NodeID: "73deadbeaf13", Hostname: "override"},
+		Reported: &data.NodeInfo{NodeID: "73deadbeaf13", Hostname: "reported"},
+		Override: &runtime.Override{Hostname: "override"},
+	})
+
+	buf := make([]byte, 8192)
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	assert.NoError(err)
+
+	res := &data.ResponseData{}
+	assert.NoError(json.NewDecoder(flate.NewReader(bytes.NewReader(buf[:n]))).Decode(res))
+	assert.Equal("reported", res.NodeInfo.Hostname)
+}
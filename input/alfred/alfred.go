@@ -0,0 +1,301 @@
+// Package alfred reads the node data of gluon (data types 158, 159 and 160)
+// and the topology of batadv-vis from alfred, e.g. of older parts of a network without respondd
+package alfred
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/input"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// kinds of the data in alfred by their data type
+var kinds = map[uint8]string{
+	typeNodeInfo:   data.SectionNodeInfo,
+	typeStatistics: data.SectionStatistics,
+	typeNeighbours: data.SectionNeighbours,
+	typeVis:        "vis",
+}
+
+// Alfred reads alfred periodically and pushes the nodes like responses of respondd
+type Alfred struct {
+	input.Input
+	source   string
+	interval time.Duration
+	socket   string
+	files    map[string]string
+
+	nodes *runtime.Nodes
+	sink  input.Sink
+	stop  chan interface{}
+	wg    sync.WaitGroup
+}
+
+// collection is the read data of alfred by the MAC address of the source
+type collection struct {
+	responses map[string]*data.ResponseData
+	vis       []*visNode
+}
+
+func init() {
+	input.RegisterAdapter("alfred", Register)
+}
+
+func Register(configuration map[string]interface{}) (input.Input, error) {
+	var config Config
+	config = configuration
+
+	interval, err := config.Interval()
+	if err != nil {
+		return nil, err
+	}
+	a := &Alfred{
+		source:   config.Source(),
+		interval: interval,
+		socket:   config.Socket(),
+		files:    config.Files(),
+		stop:     make(chan interface{}),
+	}
+	for kind := range a.files {
+		if kind != "vis" && kind != data.SectionNodeInfo && kind != data.SectionStatistics && kind != data.SectionNeighbours {
+			return nil, fmt.Errorf("unknown kind of file '%s'", kind)
+		}
+	}
+	if a.socket == "" && len(a.files) == 0 {
+		return nil, errors.New("neither socket nor files given")
+	}
+	return a, nil
+}
+
+// Start reads immediately and then every interval
+func (a *Alfred) Start(nodes *runtime.Nodes, sink input.Sink) {
+	a.nodes = nodes
+	a.sink = sink
+	a.wg.Add(1)
+	go a.worker()
+}
+
+// Close stops reading
+func (a *Alfred) Close() {
+	close(a.stop)
+	a.wg.Wait()
+}
+
+func (a *Alfred) worker() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	a.readOnce()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			a.readOnce()
+		}
+	}
+}
+
+// readOnce reads the socket and the files and pushes the nodes
+func (a *Alfred) readOnce() {
+	c := &collection{responses: make(map[string]*data.ResponseData)}
+
+	if a.socket != "" {
+		for dataType, kind := range kinds {
+			records, err := request(a.socket, dataType)
+			if err != nil {
+				log.Printf("[alfred] unable to request %s: %s", kind, err)
+				continue
+			}
+			for _, r := range records {
+				if err = c.addRecord(kind, r); err != nil {
+					log.Printf("[alfred] invalid %s of %s: %s", kind, r.source, err)
+				}
+			}
+		}
+	}
+
+	for kind, path := range a.files {
+		raw, err := ioutil.ReadFile(path)
+		if err == nil {
+			err = c.addFile(kind, raw)
+		}
+		if err != nil {
+			log.Printf("[alfred] unable to read %s: %s", path, err)
+		}
+	}
+
+	pushed := 0
+	for _, res := range a.responses(c) {
+		if err := a.sink.Push(a.source, res); err != nil {
+			log.Printf("[alfred] %s", err)
+			continue
+		}
+		pushed++
+	}
+	log.Printf("[alfred] pushed %d nodes", pushed)
+}
+
+// addRecord adds the data of a source in alfred
+func (c *collection) addRecord(kind string, r record) error {
+	if kind == "vis" {
+		if r.version != visVersion {
+			return fmt.Errorf("unknown version %d", r.version)
+		}
+		node, err := parseVis(r.data)
+		if err != nil {
+			return err
+		}
+		c.vis = append(c.vis, node)
+		return nil
+	}
+	raw, err := decompress(r.data)
+	if err != nil {
+		return err
+	}
+	return c.addSection(kind, r.source, raw)
+}
+
+// addFile adds a dump of alfred-json (the data by the MAC address of the source)
+// or of batadv-vis in the format jsondoc
+func (c *collection) addFile(kind string, raw []byte) error {
+	if kind == "vis" {
+		nodes, err := parseVisJSON(raw)
+		if err != nil {
+			return err
+		}
+		c.vis = append(c.vis, nodes...)
+		return nil
+	}
+
+	var dump map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &dump); err != nil {
+		return err
+	}
+	for source, section := range dump {
+		// alfred-json prints data which it is unable to parse as string
+		var str string
+		if json.Unmarshal(section, &str) == nil {
+			section = json.RawMessage(str)
+		}
+		if err := c.addSection(kind, source, section); err != nil {
+			log.Printf("[alfred] invalid %s of %s: %s", kind, source, err)
+		}
+	}
+	return nil
+}
+
+// addSection decodes a section of a source
+func (c *collection) addSection(kind, source string, raw []byte) error {
+	var section interface{}
+	switch kind {
+	case data.SectionNodeInfo:
+		section = &data.NodeInfo{}
+	case data.SectionStatistics:
+		section = &data.Statistics{}
+	case data.SectionNeighbours:
+		section = &data.Neighbours{}
+	default:
+		return fmt.Errorf("unknown kind '%s'", kind)
+	}
+	if err := json.Unmarshal(raw, section); err != nil {
+		return err
+	}
+
+	source = strings.ToLower(source)
+	res := c.responses[source]
+	if res == nil {
+		res = &data.ResponseData{}
+		c.responses[source] = res
+	}
+	switch section := section.(type) {
+	case *data.NodeInfo:
+		res.NodeInfo = section
+	case *data.Statistics:
+		res.Statistics = section
+	case *data.Neighbours:
+		res.Neighbours = section
+	}
+	return nil
+}
+
+// responses completes the NodeIDs of the collected sections
+// and adds the topology of batadv-vis to nodes without neighbours
+func (a *Alfred) responses(c *collection) []*data.ResponseData {
+	// NodeIDs by MAC addresses
+	nodeIDs := make(map[string]string)
+	list := make([]*data.ResponseData, 0, len(c.responses))
+	byNodeID := make(map[string]*data.ResponseData)
+	for source, res := range c.responses {
+		nodeID := res.NodeID()
+		if nodeID == "" && res.NodeInfo != nil && res.NodeInfo.Network.Mac != "" {
+			nodeID = nodeIDOf(res.NodeInfo.Network.Mac)
+		}
+		if nodeID == "" {
+			nodeID = nodeIDOf(source)
+		}
+		setNodeID(res, nodeID)
+		list = append(list, res)
+		byNodeID[nodeID] = res
+
+		nodeIDs[source] = nodeID
+		if res.NodeInfo != nil {
+			nodeIDs[res.NodeInfo.Network.Mac] = nodeID
+			for _, iface := range res.NodeInfo.Network.Mesh {
+				for _, addr := range iface.Addresses() {
+					nodeIDs[addr] = nodeID
+				}
+			}
+		}
+	}
+
+	for _, vis := range c.vis {
+		nodeID := nodeIDs[vis.primary]
+		if nodeID == "" && a.nodes != nil {
+			a.nodes.RLock()
+			nodeID = a.nodes.GetNodeIDbyAddress(vis.primary)
+			a.nodes.RUnlock()
+		}
+		if nodeID == "" {
+			continue
+		}
+
+		res := byNodeID[nodeID]
+		if res == nil {
+			res = &data.ResponseData{}
+			list = append(list, res)
+			byNodeID[nodeID] = res
+		}
+		if res.Neighbours == nil {
+			res.Neighbours = vis.neighbours(nodeID)
+		}
+	}
+	return list
+}
+
+// setNodeID sets the NodeID of the sections without one (e.g. of older firmwares)
+func setNodeID(res *data.ResponseData, nodeID string) {
+	if res.NodeInfo != nil && res.NodeInfo.NodeID == "" {
+		res.NodeInfo.NodeID = nodeID
+	}
+	if res.Statistics != nil && res.Statistics.NodeID == "" {
+		res.Statistics.NodeID = nodeID
+	}
+	if res.Neighbours != nil && res.Neighbours.NodeID == "" {
+		res.Neighbours.NodeID = nodeID
+	}
+}
+
+// nodeIDOf returns the NodeID of gluon for a MAC address
+func nodeIDOf(mac string) string {
+	return strings.Replace(strings.ToLower(mac), ":", "", -1)
+}
@@ -0,0 +1,263 @@
+package alfred
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type testSink struct {
+	sync.Mutex
+	sources   []string
+	responses []*data.ResponseData
+}
+
+func (s *testSink) Push(source string, res *data.ResponseData) error {
+	s.Lock()
+	defer s.Unlock()
+	s.sources = append(s.sources, source)
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func (s *testSink) Merge(source string, node *runtime.FederatedNode) error {
+	return nil
+}
+
+func (s *testSink) byNodeID() map[string]*data.ResponseData {
+	s.Lock()
+	defer s.Unlock()
+	result := make(map[string]*data.ResponseData)
+	for _, res := range s.responses {
+		result[res.NodeID()] = res
+	}
+	return result
+}
+
+func gzipped(raw string) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	writer.Write([]byte(raw))
+	writer.Close()
+	return buf.Bytes()
+}
+
+// alfredData returns an alfred_data record
+func alfredData(source string, dataType, version uint8, raw []byte) []byte {
+	mac, _ := net.ParseMAC(source)
+	record := append([]byte{}, mac...)
+	record = append(record, dataType, version, 0, 0)
+	binary.BigEndian.PutUint16(record[8:], uint16(len(raw)))
+	return append(record, raw...)
+}
+
+// fakeAlfred answers requests like the unix socket of alfred
+func fakeAlfred(t *testing.T, socket string, records map[uint8][][]byte) net.Listener {
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			req := make([]byte, 7)
+			if _, err = io.ReadFull(conn, req); err == nil && req[0] == alfredRequest {
+				for _, record := range records[req[4]] {
+					packet := []byte{alfredPushData, alfredVersion, 0, 0, 0, 1, 0, 0}
+					binary.BigEndian.PutUint16(packet[2:], uint16(4+len(record)))
+					conn.Write(append(packet, record...))
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return listener
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{})
+	assert.Error(err, "nothing to read")
+
+	_, err = Register(map[string]interface{}{"socket": "/var/run/alfred.sock", "interval": "5x"})
+	assert.Error(err)
+
+	_, err = Register(map[string]interface{}{
+		"files": map[string]interface{}{"unknown": "/tmp/unknown.json"},
+	})
+	assert.Error(err)
+
+	in, err := Register(map[string]interface{}{
+		"socket": "/var/run/alfred.sock",
+		"files":  map[string]interface{}{"vis": "/tmp/vis.json"},
+	})
+	assert.NoError(err)
+	a := in.(*Alfred)
+	assert.Equal(sourceDefault, a.source)
+	assert.Equal(intervalDefault, a.interval)
+	assert.Equal(map[string]string{"vis": "/tmp/vis.json"}, a.files)
+}
+
+func TestSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-alfred")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "alfred.sock")
+
+	// vis of the node with one interface and a neighbour and a client
+	vis := []byte{0xf8, 0x1a, 0x67, 0xa6, 0x01, 0xea, 1, 2}
+	vis = append(vis, 0xf8, 0x1a, 0x67, 0xa6, 0x01, 0xe0)
+	vis = append(vis, 0xf8, 0x1a, 0x67, 0xa6, 0x01, 0xf0, 0, 200)
+	vis = append(vis, 0x02, 0x00, 0x00, 0x00, 0x00, 0x01, 255, 0)
+
+	listener := fakeAlfred(t, socket, map[uint8][][]byte{
+		typeNodeInfo: {
+			alfredData("f8:1a:67:a6:01:ea", typeNodeInfo, 0, gzipped(`{"node_id":"f81a67a601ea","hostname":"alfred-node"}`)),
+			// older firmware without NodeID
+			alfredData("f8:1a:67:a6:01:eb", typeNodeInfo, 0, []byte(`{"hostname":"legacy","network":{"mac":"F8:1A:67:A6:01:EC"}}`)),
+		},
+		typeStatistics: {
+			alfredData("f8:1a:67:a6:01:ea", typeStatistics, 0, gzipped(`{"node_id":"f81a67a601ea","clients":{"total":7}}`)),
+			alfredData("f8:1a:67:a6:01:eb", typeStatistics, 0, []byte(`{"clients":{"total":3}}`)),
+		},
+		typeVis: {alfredData("f8:1a:67:a6:01:ea", typeVis, visVersion, vis)},
+	})
+	defer listener.Close()
+
+	records, err := request(socket, typeNodeInfo)
+	assert.NoError(err)
+	assert.Len(records, 2)
+	assert.Equal("f8:1a:67:a6:01:ea", records[0].source)
+
+	records, err = request(socket, typeNeighbours)
+	assert.NoError(err)
+	assert.Len(records, 0)
+
+	_, err = request(filepath.Join(dir, "missing.sock"), typeNodeInfo)
+	assert.Error(err)
+
+	in, err := Register(map[string]interface{}{"socket": socket, "source": "alfred-old"})
+	assert.NoError(err)
+	a := in.(*Alfred)
+	sink := &testSink{}
+	a.sink = sink
+	a.readOnce()
+
+	responses := sink.byNodeID()
+	assert.Len(responses, 2)
+	assert.Equal("alfred-old", sink.sources[0])
+
+	res := responses["f81a67a601ea"]
+	assert.Equal("alfred-node", res.NodeInfo.Hostname)
+	assert.EqualValues(7, res.Statistics.Clients.Total)
+	assert.Equal(map[string]data.BatmanLink{"f8:1a:67:a6:01:f0": {Tq: 200}},
+		res.Neighbours.Batadv["f8:1a:67:a6:01:e0"].Neighbours)
+
+	res = responses["f81a67a601ec"]
+	assert.Equal("legacy", res.NodeInfo.Hostname)
+	assert.Equal("f81a67a601ec", res.Statistics.NodeID)
+	assert.Nil(res.Neighbours)
+}
+
+func TestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-alfred")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	nodeinfo := filepath.Join(dir, "158.json")
+	ioutil.WriteFile(nodeinfo, []byte(`{
+		"f8:1a:67:a6:01:ea": {"node_id":"f81a67a601ea","hostname":"alfred-node"},
+		"f8:1a:67:a6:01:eb": "{\"node_id\":\"f81a67a601eb\",\"hostname\":\"as-string\"}",
+		"f8:1a:67:a6:01:ec": "unparseable"
+	}`), 0644)
+	vis := filepath.Join(dir, "vis.json")
+	ioutil.WriteFile(vis, []byte(`{"source_version":"2017.1","algorithm":4,"vis":[
+		{"primary":"f8:1a:67:a6:01:ea","neighbors":[
+			{"router":"f8:1a:67:a6:01:e0","neighbor":"f8:1a:67:a6:01:f0","metric":"1.275"},
+			{"router":"f8:1a:67:a6:01:e0","neighbor":"f8:1a:67:a6:01:f1","metric":"invalid"}
+		],"clients":["02:00:00:00:00:01"]},
+		{"primary":"f8:1a:67:a6:01:ed","neighbors":[]},
+		{"primary":"f8:1a:67:a6:01:ee","neighbors":[]}
+	]}`), 0644)
+
+	in, err := Register(map[string]interface{}{"files": map[string]interface{}{
+		"nodeinfo":   nodeinfo,
+		"vis":        vis,
+		"statistics": filepath.Join(dir, "missing.json"),
+	}})
+	assert.NoError(err)
+	a := in.(*Alfred)
+	sink := &testSink{}
+	a.sink = sink
+
+	// a node which is only known by its address
+	a.nodes = runtime.NewNodes(&runtime.NodesConfig{})
+	a.nodes.AddNode(&runtime.Node{Nodeinfo: &data.NodeInfo{NodeID: "f81a67a601ed", Network: data.Network{Mac: "f8:1a:67:a6:01:ed"}}})
+	a.readOnce()
+
+	responses := sink.byNodeID()
+	assert.Len(responses, 3)
+	assert.Equal("as-string", responses["f81a67a601eb"].NodeInfo.Hostname)
+	assert.Equal(map[string]data.BatmanLink{"f8:1a:67:a6:01:f0": {Tq: 200}},
+		responses["f81a67a601ea"].Neighbours.Batadv["f8:1a:67:a6:01:e0"].Neighbours)
+	assert.NotNil(responses["f81a67a601ed"].Neighbours)
+	assert.Nil(responses["f81a67a601ed"].NodeInfo)
+}
+
+func TestDecompress(t *testing.T) {
+	assert := assert.New(t)
+
+	raw, err := decompress(gzipped("{}"))
+	assert.NoError(err)
+	assert.Equal([]byte("{}"), raw)
+
+	raw, err = decompress([]byte("{}"))
+	assert.NoError(err)
+	assert.Equal([]byte("{}"), raw)
+
+	_, err = decompress([]byte{0x1f, 0x8b, 0})
+	assert.Error(err)
+
+	// small record, which inflates above the limit
+	bomb := gzipped(strings.Repeat(" ", maxDataSize+1))
+	assert.True(len(bomb) < 65535)
+	_, err = decompress(bomb)
+	assert.Error(err)
+
+	raw, err = decompress(gzipped(strings.Repeat(" ", maxDataSize)))
+	assert.NoError(err)
+	assert.Len(raw, maxDataSize)
+}
+
+func TestParseVis(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := parseVis([]byte{1, 2, 3})
+	assert.Error(err)
+	_, err = parseVis([]byte{0xf8, 0x1a, 0x67, 0xa6, 0x01, 0xea, 1, 1})
+	assert.Error(err)
+
+	_, err = parseVisJSON([]byte("invalid"))
+	assert.Error(err)
+}
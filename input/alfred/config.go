@@ -0,0 +1,58 @@
+package alfred
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+const (
+	sourceDefault   = "alfred"
+	intervalDefault = time.Minute
+)
+
+type Config map[string]interface{}
+
+// Source is the label of the updated nodes
+func (c Config) Source() string {
+	if source, ok := c["source"].(string); ok && source != "" {
+		return source
+	}
+	return sourceDefault
+}
+
+// Interval is how often alfred is read
+func (c Config) Interval() (time.Duration, error) {
+	value, ok := c["interval"].(string)
+	if !ok || value == "" {
+		return intervalDefault, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid interval: %s", err)
+	}
+	return d.Duration, nil
+}
+
+// Socket is the path of the unix socket of alfred
+func (c Config) Socket() string {
+	if socket, ok := c["socket"].(string); ok {
+		return socket
+	}
+	return ""
+}
+
+// Files are the paths of dumps by alfred-json (nodeinfo, statistics and neighbours)
+// and batadv-vis (vis)
+func (c Config) Files() map[string]string {
+	files := make(map[string]string)
+	if list, ok := c["files"].(map[string]interface{}); ok {
+		for kind, path := range list {
+			if path, ok := path.(string); ok && path != "" {
+				files[kind] = path
+			}
+		}
+	}
+	return files
+}
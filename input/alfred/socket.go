@@ -0,0 +1,145 @@
+package alfred
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// packet types of the alfred protocol
+const (
+	alfredPushData    = 0
+	alfredRequest     = 2
+	alfredStatusError = 4
+	alfredVersion     = 0
+)
+
+// data types of gluon and batadv-vis in alfred
+const (
+	typeVis        = 1
+	typeNodeInfo   = 158
+	typeStatistics = 159
+	typeNeighbours = 160
+)
+
+// socketTimeout of a request to alfred
+const socketTimeout = time.Second * 10
+
+// maxDataSize of the decompressed data of a record, any node is able to write into alfred
+const maxDataSize = 1 << 20
+
+// record is the data of a source in alfred
+type record struct {
+	source  string // MAC address of the source
+	version uint8
+	data    []byte
+}
+
+// request reads all records of a data type from the unix socket of alfred
+func request(socket string, dataType uint8) ([]record, error) {
+	conn, err := net.DialTimeout("unix", socket, socketTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(socketTimeout))
+
+	// alfred_request_v0: tlv header, requested type and transaction id
+	req := make([]byte, 7)
+	req[0] = alfredRequest
+	req[1] = alfredVersion
+	binary.BigEndian.PutUint16(req[2:], 3)
+	req[4] = dataType
+	binary.BigEndian.PutUint16(req[5:], uint16(rand.Intn(1<<16)))
+	if _, err = conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	// alfred answers with push data packets and closes the connection
+	var records []record
+	header := make([]byte, 4)
+	for {
+		if _, err = io.ReadFull(conn, header); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		packet := make([]byte, binary.BigEndian.Uint16(header[2:]))
+		if _, err = io.ReadFull(conn, packet); err != nil {
+			return nil, err
+		}
+		switch header[0] {
+		case alfredPushData:
+			parsed, err := parsePushData(packet)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, parsed...)
+		case alfredStatusError:
+			return nil, fmt.Errorf("alfred answered with an error for data type %d", dataType)
+		}
+	}
+}
+
+// parsePushData parses the records of an alfred_push_data_v0 without its tlv header
+func parsePushData(packet []byte) ([]record, error) {
+	// transaction id and sequence number
+	if len(packet) < 4 {
+		return nil, errors.New("push data too short")
+	}
+	packet = packet[4:]
+
+	var records []record
+	for len(packet) > 0 {
+		// alfred_data: source MAC, tlv header and data
+		if len(packet) < 10 {
+			return nil, errors.New("record too short")
+		}
+		length := int(binary.BigEndian.Uint16(packet[8:10]))
+		if len(packet) < 10+length {
+			return nil, errors.New("record too short")
+		}
+		records = append(records, record{
+			source:  net.HardwareAddr(packet[:6]).String(),
+			version: packet[7],
+			data:    packet[10 : 10+length],
+		})
+		packet = packet[10+length:]
+	}
+	return records, nil
+}
+
+// decompress returns the data of gluon, which is usually compressed by gzip,
+// up to maxDataSize
+func decompress(raw []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch {
+	case len(raw) >= 2 && raw[0] == 0x1f && raw[1] == 0x8b:
+		reader, err = gzip.NewReader(bytes.NewReader(raw))
+	case len(raw) >= 2 && raw[0] == 0x78 && (uint16(raw[0])<<8|uint16(raw[1]))%31 == 0:
+		reader, err = zlib.NewReader(bytes.NewReader(raw))
+	default:
+		return raw, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(reader, maxDataSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDataSize {
+		return nil, fmt.Errorf("decompressed data exceeds %d bytes", maxDataSize)
+	}
+	return data, nil
+}
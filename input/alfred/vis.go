@@ -0,0 +1,109 @@
+package alfred
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+// visVersion of the binary data of batadv-vis in alfred
+const visVersion = 1
+
+// visNode is the topology of a node by batadv-vis
+type visNode struct {
+	primary string // primary MAC address
+	links   []visLink
+}
+
+// visLink is a link of an interface of the node to a neighbour
+type visLink struct {
+	router    string // MAC address of the own interface
+	neighbour string // MAC address of the neighbour
+	tq        int
+}
+
+// parseVis parses the binary data of batadv-vis (vis_v1) in alfred
+func parseVis(raw []byte) (*visNode, error) {
+	if len(raw) < 8 {
+		return nil, errors.New("vis data too short")
+	}
+	ifaceCount, entryCount := int(raw[6]), int(raw[7])
+	if len(raw) < 8+6*ifaceCount+8*entryCount {
+		return nil, errors.New("vis data too short")
+	}
+	node := &visNode{primary: net.HardwareAddr(raw[:6]).String()}
+
+	ifaces := raw[8 : 8+6*ifaceCount]
+	entries := raw[8+6*ifaceCount:]
+	for i := 0; i < entryCount; i++ {
+		entry := entries[8*i : 8*i+8]
+		ifindex, qual := int(entry[6]), int(entry[7])
+		// entries of clients have no interface (255) and quality
+		if ifindex >= ifaceCount || qual == 0 {
+			continue
+		}
+		node.links = append(node.links, visLink{
+			router:    net.HardwareAddr(ifaces[6*ifindex : 6*ifindex+6]).String(),
+			neighbour: net.HardwareAddr(entry[:6]).String(),
+			tq:        qual,
+		})
+	}
+	return node, nil
+}
+
+// parseVisJSON parses the output of batadv-vis in the format jsondoc
+func parseVisJSON(raw []byte) ([]*visNode, error) {
+	var document struct {
+		Vis []struct {
+			Primary   string `json:"primary"`
+			Neighbors []struct {
+				Router   string `json:"router"`
+				Neighbor string `json:"neighbor"`
+				Metric   string `json:"metric"`
+			} `json:"neighbors"`
+		} `json:"vis"`
+	}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+
+	var nodes []*visNode
+	for _, vis := range document.Vis {
+		node := &visNode{primary: vis.Primary}
+		for _, neighbour := range vis.Neighbors {
+			// the metric is 255 divided by the TQ
+			metric, err := strconv.ParseFloat(neighbour.Metric, 64)
+			if err != nil || metric < 1 {
+				continue
+			}
+			node.links = append(node.links, visLink{
+				router:    neighbour.Router,
+				neighbour: neighbour.Neighbor,
+				tq:        int(math.Round(255 / metric)),
+			})
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// neighbours returns the topology as batman-adv neighbours of respondd
+func (node *visNode) neighbours(nodeID string) *data.Neighbours {
+	neighbours := &data.Neighbours{
+		NodeID: nodeID,
+		Batadv: make(map[string]data.BatadvNeighbours),
+	}
+	for _, link := range node.links {
+		iface, ok := neighbours.Batadv[link.router]
+		if !ok {
+			iface = data.BatadvNeighbours{Neighbours: make(map[string]data.BatmanLink)}
+			neighbours.Batadv[link.router] = iface
+		}
+		iface.Neighbours[link.neighbour] = data.BatmanLink{Tq: link.tq}
+	}
+	return neighbours
+}
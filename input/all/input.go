@@ -0,0 +1,59 @@
+package all
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/FreifunkBremen/yanic/input"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type Input struct {
+	input.Input
+	list []input.Input
+}
+
+func Register(configuration map[string]interface{}) (input.Input, error) {
+	var list []input.Input
+	for inputType, inputRegister := range input.Adapters {
+		configForInput := configuration[inputType]
+		if configForInput == nil {
+			log.Printf("the input type '%s' has no configuration\n", inputType)
+			continue
+		}
+		inputConfigs, ok := configForInput.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("the input type '%s' has the wrong format", inputType)
+		}
+		for _, inputConfig := range inputConfigs {
+			config, ok := inputConfig.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("the input type '%s' has the wrong format", inputType)
+			}
+			if c, ok := config["enable"].(bool); ok && !c {
+				continue
+			}
+			in, err := inputRegister(config)
+			if err != nil {
+				return nil, err
+			}
+			if in == nil {
+				continue
+			}
+			list = append(list, in)
+		}
+	}
+	return &Input{list: list}, nil
+}
+
+func (in *Input) Start(nodes *runtime.Nodes, sink input.Sink) {
+	for _, item := range in.list {
+		item.Start(nodes, sink)
+	}
+}
+
+func (in *Input) Close() {
+	for _, item := range in.list {
+		item.Close()
+	}
+}
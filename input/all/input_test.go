@@ -0,0 +1,137 @@
+package all
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/input"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type testInput struct {
+	input.Input
+	sync.Mutex
+	started bool
+	closed  bool
+}
+
+func (in *testInput) Start(nodes *runtime.Nodes, sink input.Sink) {
+	in.Lock()
+	in.started = true
+	in.Unlock()
+	sink.Push("test", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "test"}})
+}
+
+func (in *testInput) Close() {
+	in.Lock()
+	in.closed = true
+	in.Unlock()
+}
+
+func TestStart(t *testing.T) {
+	assert := assert.New(t)
+
+	var inputs []*testInput
+	input.RegisterAdapter("test", func(config map[string]interface{}) (input.Input, error) {
+		in := &testInput{}
+		inputs = append(inputs, in)
+		return in, nil
+	})
+	defer delete(input.Adapters, "test")
+
+	_, err := Register(map[string]interface{}{"test": "wrong format"})
+	assert.Error(err)
+	_, err = Register(map[string]interface{}{"test": []interface{}{"wrong format"}})
+	assert.Error(err)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	err = Start(nodes, nil, map[string]interface{}{
+		"test": []interface{}{
+			map[string]interface{}{"enable": false},
+			map[string]interface{}{},
+		},
+	})
+	assert.NoError(err)
+	assert.Len(inputs, 1)
+	assert.True(inputs[0].started)
+	assert.Equal("test", nodes.List["f81a67a601ea"].Source)
+
+	Close()
+	assert.True(inputs[0].closed)
+}
+
+type testConnection struct {
+	database.Connection
+	nodes []*runtime.Node
+	links int
+}
+
+func (c *testConnection) InsertNode(node *runtime.Node) {
+	c.nodes = append(c.nodes, node)
+}
+
+func (c *testConnection) InsertLink(link *runtime.Link, t time.Time) {
+	c.links++
+}
+
+func TestSink(t *testing.T) {
+	assert := assert.New(t)
+
+	db := &testConnection{}
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	sink := NewSink(nodes, db)
+
+	assert.Error(sink.Push("test", &data.ResponseData{}))
+	assert.Len(nodes.List, 0)
+
+	nodes.AddNode(&runtime.Node{Nodeinfo: &data.NodeInfo{NodeID: "f81a67a601eb", Network: data.Network{Mac: "f8:1a:67:a6:01:eb"}}})
+	err := sink.Push("test", &data.ResponseData{
+		NodeInfo:   &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "test"},
+		Statistics: &data.Statistics{NodeID: "f81a67a601eb"},
+		Neighbours: &data.Neighbours{
+			NodeID: "f81a67a601ea",
+			Batadv: map[string]data.BatadvNeighbours{
+				"f8:1a:67:a6:01:ea": {Neighbours: map[string]data.BatmanLink{
+					"f8:1a:67:a6:01:eb": {Tq: 200},
+				}},
+			},
+		},
+	})
+	assert.NoError(err)
+
+	node := nodes.List["f81a67a601ea"]
+	assert.Equal("test", node.Source)
+	assert.Nil(node.Statistics, "section of another node")
+	assert.Len(db.nodes, 1)
+	assert.Equal(1, db.links)
+}
+
+func TestSinkMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	db := &testConnection{}
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	sink := NewSink(nodes, db)
+
+	assert.Error(sink.Merge("remote", &runtime.FederatedNode{Node: &runtime.Node{}}))
+
+	now := jsontime.Now()
+	federated := &runtime.FederatedNode{Node: &runtime.Node{
+		Lastseen: now,
+		Nodeinfo: &data.NodeInfo{NodeID: "f81a67a601ea"},
+	}}
+	assert.NoError(sink.Merge("remote", federated))
+	assert.Equal("remote", nodes.List["f81a67a601ea"].Source)
+	assert.Len(db.nodes, 1)
+
+	// not newer than the known node
+	assert.NoError(sink.Merge("other", federated))
+	assert.Equal("remote", nodes.List["f81a67a601ea"].Source)
+	assert.Len(db.nodes, 1)
+}
@@ -0,0 +1,28 @@
+package all
+
+import (
+	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/input"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+var inputA input.Input
+
+// Start registers the configured inputs and starts them,
+// they push their data to the nodes and the database
+func Start(nodes *runtime.Nodes, db database.Connection, config map[string]interface{}) (err error) {
+	inputA, err = Register(config)
+	if err != nil {
+		return
+	}
+	inputA.Start(nodes, NewSink(nodes, db))
+	return
+}
+
+// Close stops the inputs of the last Start
+func Close() {
+	if inputA != nil {
+		inputA.Close()
+		inputA = nil
+	}
+}
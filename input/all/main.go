@@ -0,0 +1,8 @@
+package all
+
+import (
+	_ "github.com/FreifunkBremen/yanic/input/alfred"
+	_ "github.com/FreifunkBremen/yanic/input/federation"
+	_ "github.com/FreifunkBremen/yanic/input/receiver"
+	_ "github.com/FreifunkBremen/yanic/input/statuspage"
+)
@@ -0,0 +1,69 @@
+package all
+
+import (
+	"fmt"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/database"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Sink stores the pushed data of the inputs to the nodes and the database
+type Sink struct {
+	nodes *runtime.Nodes
+	db    database.Connection
+}
+
+// NewSink creates a sink, the database is optional
+func NewSink(nodes *runtime.Nodes, db database.Connection) *Sink {
+	return &Sink{nodes: nodes, db: db}
+}
+
+// Push stores the data of a node like a respondd response and labels the node with its source
+func (s *Sink) Push(source string, res *data.ResponseData) error {
+	nodeID := res.NodeID()
+	if len(nodeID) != 12 {
+		return fmt.Errorf("invalid NodeID '%s' from %s", nodeID, source)
+	}
+	res.DropMismatches(nodeID)
+
+	node := s.nodes.Update(nodeID, res)
+	s.nodes.Lock()
+	node.Source = source
+	s.nodes.Unlock()
+
+	s.store(node)
+	return nil
+}
+
+// Merge stores a node of another instance, if it is newer, and labels it with its source
+func (s *Sink) Merge(source string, federated *runtime.FederatedNode) error {
+	if federated.Node == nil || federated.Nodeinfo == nil || len(federated.Nodeinfo.NodeID) != 12 {
+		return fmt.Errorf("invalid node from %s", source)
+	}
+	federated.Source = source
+	if node := s.nodes.Merge(federated); node != nil {
+		s.store(node)
+	}
+	return nil
+}
+
+// store inserts the node and its links into the database
+func (s *Sink) store(node *runtime.Node) {
+	if s.db == nil {
+		return
+	}
+
+	s.nodes.RLock()
+	stored := *node
+	var links []runtime.Link
+	if node.Neighbours != nil {
+		links = s.nodes.NodeLinks(node)
+	}
+	s.nodes.RUnlock()
+
+	s.db.InsertNode(&stored)
+	for i := range links {
+		s.db.InsertLink(&links[i], stored.Lastseen.GetTime())
+	}
+}
@@ -0,0 +1,68 @@
+package federation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+const (
+	intervalDefault = time.Minute
+	timeoutDefault  = time.Second * 30
+)
+
+type Config map[string]interface{}
+
+// remote is another yanic instance
+type remote struct {
+	name string
+	url  string
+}
+
+// Interval is how often the remotes are pulled
+func (c Config) Interval() (time.Duration, error) {
+	return c.duration("interval", intervalDefault)
+}
+
+// Timeout of a request
+func (c Config) Timeout() (time.Duration, error) {
+	return c.duration("timeout", timeoutDefault)
+}
+
+// Remotes are the instances to pull with their name and URL
+func (c Config) Remotes() ([]remote, error) {
+	list, _ := c["remote"].([]interface{})
+	var remotes []remote
+	names := make(map[string]bool)
+	for _, item := range list {
+		config, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("the remote has the wrong format")
+		}
+		name, _ := config["name"].(string)
+		url, _ := config["url"].(string)
+		if name == "" || url == "" {
+			return nil, errors.New("every remote needs a name and an url")
+		}
+		if names[name] {
+			return nil, fmt.Errorf("remote %s is configured twice", name)
+		}
+		names[name] = true
+		remotes = append(remotes, remote{name: name, url: url})
+	}
+	return remotes, nil
+}
+
+func (c Config) duration(key string, def time.Duration) (time.Duration, error) {
+	value, ok := c[key].(string)
+	if !ok || value == "" {
+		return def, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", key, err)
+	}
+	return d.Duration, nil
+}
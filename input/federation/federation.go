@@ -0,0 +1,135 @@
+// Package federation pulls the nodes of other yanic instances (e.g. of other segments)
+// and merges them by their last seen into the own nodes
+package federation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/input"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Federation pulls the remotes periodically
+type Federation struct {
+	input.Input
+	interval time.Duration
+	remotes  []remote
+	client   *http.Client
+
+	sink input.Sink
+	stop chan interface{}
+	wg   sync.WaitGroup
+}
+
+func init() {
+	input.RegisterAdapter("federation", Register)
+}
+
+func Register(configuration map[string]interface{}) (input.Input, error) {
+	var config Config
+	config = configuration
+
+	interval, err := config.Interval()
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := config.Timeout()
+	if err != nil {
+		return nil, err
+	}
+	remotes, err := config.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	if len(remotes) == 0 {
+		return nil, errors.New("no remote configured")
+	}
+	return &Federation{
+		interval: interval,
+		remotes:  remotes,
+		client:   &http.Client{Timeout: timeout},
+		stop:     make(chan interface{}),
+	}, nil
+}
+
+// Start pulls immediately and then every interval
+func (f *Federation) Start(nodes *runtime.Nodes, sink input.Sink) {
+	f.sink = sink
+	f.wg.Add(1)
+	go f.worker()
+}
+
+// Close stops pulling and waits for the running requests
+func (f *Federation) Close() {
+	close(f.stop)
+	f.wg.Wait()
+}
+
+func (f *Federation) worker() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	f.pullAll()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.pullAll()
+		}
+	}
+}
+
+// pullAll pulls all remotes in parallel
+func (f *Federation) pullAll() {
+	var wg sync.WaitGroup
+	for _, r := range f.remotes {
+		wg.Add(1)
+		go func(r remote) {
+			defer wg.Done()
+			count, err := f.pull(r)
+			if err != nil {
+				log.Printf("[federation] unable to pull %s: %s", r.name, err)
+				return
+			}
+			log.Printf("[federation] pulled %d nodes of %s", count, r.name)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// pull requests the nodes of a remote and merges them,
+// it returns the count of received nodes
+func (f *Federation) pull(r remote) (int, error) {
+	resp, err := f.client.Get(r.url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s: %s", r.url, resp.Status)
+	}
+
+	var federated runtime.FederatedNodes
+	if err = json.NewDecoder(resp.Body).Decode(&federated); err != nil {
+		return 0, err
+	}
+
+	for _, node := range federated.Nodes {
+		if node == nil || node.Node == nil {
+			continue
+		}
+		if err := f.sink.Merge(r.name, node); err != nil {
+			log.Printf("[federation] %s", err)
+		}
+	}
+	return len(federated.Nodes), nil
+}
@@ -0,0 +1,115 @@
+package federation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type testSink struct {
+	sync.Mutex
+	sources []string
+	nodes   []*runtime.FederatedNode
+}
+
+func (s *testSink) Push(source string, res *data.ResponseData) error {
+	return nil
+}
+
+func (s *testSink) Merge(source string, node *runtime.FederatedNode) error {
+	s.Lock()
+	defer s.Unlock()
+	s.sources = append(s.sources, source)
+	s.nodes = append(s.nodes, node)
+	return nil
+}
+
+// remoteInstance returns a fake yanic instance with the output of the federation
+func remoteInstance(nodeIDs ...string) *httptest.Server {
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	for _, nodeID := range nodeIDs {
+		nodes.AddNode(&runtime.Node{
+			Nodeinfo:   &data.NodeInfo{NodeID: nodeID},
+			Neighbours: &data.Neighbours{NodeID: nodeID},
+		})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/federation.json" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(runtime.NewFederatedNodes(nodes))
+	}))
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{})
+	assert.Error(err, "no remote")
+
+	_, err = Register(map[string]interface{}{
+		"remote": []interface{}{map[string]interface{}{"name": "north"}},
+	})
+	assert.Error(err, "no url")
+
+	remotes := []interface{}{
+		map[string]interface{}{"name": "north", "url": "http://north.example.org/federation.json"},
+	}
+	_, err = Register(map[string]interface{}{"remote": append(remotes, remotes[0])})
+	assert.Error(err, "configured twice")
+
+	_, err = Register(map[string]interface{}{"remote": remotes, "timeout": "5x"})
+	assert.Error(err)
+
+	in, err := Register(map[string]interface{}{"remote": remotes, "interval": "5m"})
+	assert.NoError(err)
+	f := in.(*Federation)
+	assert.Equal(time.Minute*5, f.interval)
+	assert.Equal(timeoutDefault, f.client.Timeout)
+	assert.Equal([]remote{{name: "north", url: "http://north.example.org/federation.json"}}, f.remotes)
+}
+
+func TestPull(t *testing.T) {
+	assert := assert.New(t)
+
+	north := remoteInstance("f81a67a601ea", "f81a67a601eb")
+	defer north.Close()
+	south := remoteInstance("f81a67a601ec")
+	defer south.Close()
+
+	in, err := Register(map[string]interface{}{
+		"remote": []interface{}{
+			map[string]interface{}{"name": "north", "url": north.URL + "/federation.json"},
+			map[string]interface{}{"name": "south", "url": south.URL + "/federation.json"},
+			map[string]interface{}{"name": "west", "url": south.URL + "/missing.json"},
+		},
+	})
+	assert.NoError(err)
+	sink := &testSink{}
+	in.Start(nil, sink)
+	in.Close()
+
+	sources := make(map[string]string)
+	for i, node := range sink.nodes {
+		sources[node.Nodeinfo.NodeID] = sink.sources[i]
+		assert.NotNil(node.Neighbours)
+	}
+	assert.Equal(map[string]string{
+		"f81a67a601ea": "north",
+		"f81a67a601eb": "north",
+		"f81a67a601ec": "south",
+	}, sources)
+
+	f := in.(*Federation)
+	_, err = f.pull(remote{name: "west", url: south.URL + "/missing.json"})
+	assert.Error(err)
+}
@@ -0,0 +1,35 @@
+package input
+
+import (
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Input interface to use for implementation of node data sources beside respondd
+type Input interface {
+	// Start receives data until Close and pushes it to the sink,
+	// the nodes are only for reading (e.g. to poll known nodes)
+	Start(nodes *runtime.Nodes, sink Sink)
+
+	// Close stops the input
+	Close()
+}
+
+// Sink stores the data of the inputs
+type Sink interface {
+	// Push stores the data of a node, the source is a label of its origin
+	Push(source string, res *data.ResponseData) error
+
+	// Merge stores a node of another yanic instance, if it was seen later than the known node
+	Merge(source string, node *runtime.FederatedNode) error
+}
+
+// Register function with config to get an input interface
+type Register func(config map[string]interface{}) (Input, error)
+
+// Adapters is the list of registered input adapters
+var Adapters = map[string]Register{}
+
+func RegisterAdapter(name string, n Register) {
+	Adapters[name] = n
+}
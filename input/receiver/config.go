@@ -0,0 +1,91 @@
+package receiver
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/lib/duration"
+	"github.com/FreifunkBremen/yanic/lib/push"
+)
+
+const maxAgeDefault = time.Minute * 5
+
+const idleTimeoutDefault = time.Minute * 10
+
+type Config map[string]interface{}
+
+// sender is an allowed yanic instance, which pushes its data
+type sender struct {
+	auth push.Auth
+	key  []byte
+}
+
+// TCPAddress to listen for streams of frames
+func (c Config) TCPAddress() string {
+	if address, ok := c["tcp_address"].(string); ok {
+		return address
+	}
+	return ""
+}
+
+// UDPAddress to listen for frames in datagrams
+func (c Config) UDPAddress() string {
+	if address, ok := c["udp_address"].(string); ok {
+		return address
+	}
+	return ""
+}
+
+// MaxAge of a signed frame, older frames are rejected as replay
+func (c Config) MaxAge() (time.Duration, error) {
+	value, ok := c["max_age"].(string)
+	if !ok || value == "" {
+		return maxAgeDefault, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid max_age: %s", err)
+	}
+	return d.Duration, nil
+}
+
+// IdleTimeout after which a TCP connection without frames is closed
+func (c Config) IdleTimeout() (time.Duration, error) {
+	value, ok := c["idle_timeout"].(string)
+	if !ok || value == "" {
+		return idleTimeoutDefault, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid idle_timeout: %s", err)
+	}
+	return d.Duration, nil
+}
+
+// Senders are the allowed instances by their name
+func (c Config) Senders() (map[string]sender, error) {
+	list, _ := c["sender"].([]interface{})
+	senders := make(map[string]sender, len(list))
+	for _, item := range list {
+		config, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("the sender has the wrong format")
+		}
+		name, _ := config["name"].(string)
+		key, _ := config["key"].(string)
+		if name == "" || key == "" {
+			return nil, errors.New("every sender needs a name and a key")
+		}
+		authName, _ := config["auth"].(string)
+		auth, err := push.ParseAuth(authName)
+		if err != nil {
+			return nil, fmt.Errorf("sender %s: %s", name, err)
+		}
+		if _, ok := senders[name]; ok {
+			return nil, fmt.Errorf("sender %s is configured twice", name)
+		}
+		senders[name] = sender{auth: auth, key: []byte(key)}
+	}
+	return senders, nil
+}
@@ -0,0 +1,235 @@
+// Package receiver listens for the data pushed by the respondd database
+// of other yanic instances (e.g. regional collectors feeding a central one)
+package receiver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/input"
+	"github.com/FreifunkBremen/yanic/lib/push"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// maxDataGramSize of a received UDP frame
+const maxDataGramSize = 65535
+
+// maxPayloadSize of the inflated data of a frame
+const maxPayloadSize = 4 << 20
+
+// Receiver accepts frames of the configured senders by TCP and UDP
+// and pushes the nodes labeled with the name of the sender
+type Receiver struct {
+	input.Input
+	senders     map[string]sender
+	maxAge      time.Duration
+	idleTimeout time.Duration
+	replays     *replayCache
+	tcp         net.Listener
+	udp         *net.UDPConn
+
+	sink    input.Sink
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+func init() {
+	input.RegisterAdapter("receiver", Register)
+}
+
+func Register(configuration map[string]interface{}) (input.Input, error) {
+	var config Config
+	config = configuration
+
+	senders, err := config.Senders()
+	if err != nil {
+		return nil, err
+	}
+	if len(senders) == 0 {
+		return nil, errors.New("no sender configured")
+	}
+	maxAge, err := config.MaxAge()
+	if err != nil {
+		return nil, err
+	}
+	idleTimeout, err := config.IdleTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if config.TCPAddress() == "" && config.UDPAddress() == "" {
+		return nil, errors.New("neither tcp_address nor udp_address given")
+	}
+
+	r := &Receiver{
+		senders:     senders,
+		maxAge:      maxAge,
+		idleTimeout: idleTimeout,
+		replays:     newReplayCache(),
+		conns:       make(map[net.Conn]struct{}),
+	}
+	if address := config.TCPAddress(); address != "" {
+		r.tcp, err = net.Listen("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if address := config.UDPAddress(); address != "" {
+		addr, err := net.ResolveUDPAddr("udp", address)
+		if err == nil {
+			r.udp, err = net.ListenUDP("udp", addr)
+		}
+		if err != nil {
+			if r.tcp != nil {
+				r.tcp.Close()
+			}
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Start accepts the connections and datagrams
+func (r *Receiver) Start(nodes *runtime.Nodes, sink input.Sink) {
+	r.sink = sink
+	if r.tcp != nil {
+		log.Printf("[receiver] listening on tcp %s", r.tcp.Addr())
+		r.wg.Add(1)
+		go r.acceptTCP()
+	}
+	if r.udp != nil {
+		log.Printf("[receiver] listening on udp %s", r.udp.LocalAddr())
+		r.wg.Add(1)
+		go r.receiveUDP()
+	}
+}
+
+// Close closes the listeners and all connections
+func (r *Receiver) Close() {
+	r.connsMu.Lock()
+	r.closed = true
+	for conn := range r.conns {
+		conn.Close()
+	}
+	r.connsMu.Unlock()
+
+	if r.tcp != nil {
+		r.tcp.Close()
+	}
+	if r.udp != nil {
+		r.udp.Close()
+	}
+	r.wg.Wait()
+}
+
+func (r *Receiver) acceptTCP() {
+	defer r.wg.Done()
+	for {
+		conn, err := r.tcp.Accept()
+		if err != nil {
+			return
+		}
+		r.connsMu.Lock()
+		if r.closed {
+			r.connsMu.Unlock()
+			conn.Close()
+			return
+		}
+		r.conns[conn] = struct{}{}
+		r.connsMu.Unlock()
+
+		r.wg.Add(1)
+		go r.readTCP(conn)
+	}
+}
+
+// readTCP reads frames of a connection until it is closed,
+// the connection is dropped on the first invalid frame or after the idle timeout
+func (r *Receiver) readTCP(conn net.Conn) {
+	defer r.wg.Done()
+	defer func() {
+		r.connsMu.Lock()
+		delete(r.conns, conn)
+		r.connsMu.Unlock()
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		if r.idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(r.idleTimeout))
+		}
+		frame, err := push.ReadFrame(reader)
+		if err != nil {
+			return
+		}
+		if err = r.handle(frame); err != nil {
+			log.Printf("[receiver] closing connection of %s: %s", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+func (r *Receiver) receiveUDP() {
+	defer r.wg.Done()
+	buf := make([]byte, maxDataGramSize)
+	for {
+		n, src, err := r.udp.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		raw := make([]byte, n)
+		copy(raw, buf)
+		if err = r.handle(raw); err != nil {
+			log.Printf("[receiver] drop datagram of %s: %s", src, err)
+		}
+	}
+}
+
+// handle verifies a frame and pushes its node
+func (r *Receiver) handle(raw []byte) error {
+	frame, err := push.Decode(raw)
+	if err != nil {
+		return err
+	}
+	sender, ok := r.senders[frame.Name]
+	if !ok {
+		return fmt.Errorf("unknown sender '%s'", frame.Name)
+	}
+	now := time.Now()
+	if err = frame.Verify(sender.auth, sender.key, now, r.maxAge); err != nil {
+		return err
+	}
+	if sender.auth == push.AuthHMAC && !r.replays.add(frame, r.maxAge, now) {
+		return fmt.Errorf("frame of %s was already received", frame.Name)
+	}
+
+	payload, err := ioutil.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(frame.Payload)), maxPayloadSize+1))
+	if err != nil {
+		return fmt.Errorf("unable to inflate data of %s: %s", frame.Name, err)
+	}
+	if len(payload) > maxPayloadSize {
+		return fmt.Errorf("data of %s exceeds %d bytes", frame.Name, maxPayloadSize)
+	}
+	res := &data.ResponseData{}
+	if err = json.Unmarshal(payload, res); err != nil {
+		return fmt.Errorf("unable to decode data of %s: %s", frame.Name, err)
+	}
+	if err = r.sink.Push(frame.Name, res); err != nil {
+		// invalid data of an authenticated sender does not close its connection
+		log.Printf("[receiver] %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package receiver
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/database/respondd"
+	"github.com/FreifunkBremen/yanic/lib/push"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type testSink struct {
+	sync.Mutex
+	sources   []string
+	responses []*data.ResponseData
+}
+
+func (s *testSink) Push(source string, res *data.ResponseData) error {
+	s.Lock()
+	defer s.Unlock()
+	s.sources = append(s.sources, source)
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func (s *testSink) Merge(source string, node *runtime.FederatedNode) error {
+	return nil
+}
+
+func (s *testSink) count() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.responses)
+}
+
+func testNode(nodeID string) *runtime.Node {
+	return &runtime.Node{
+		Nodeinfo:   &data.NodeInfo{NodeID: nodeID, Hostname: "pushed"},
+		Statistics: &data.Statistics{NodeID: nodeID, Clients: data.Clients{Total: 42}},
+	}
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{"tcp_address": "127.0.0.1:0"})
+	assert.Error(err, "no sender")
+
+	senders := []interface{}{map[string]interface{}{"name": "north", "key": "secret"}}
+	_, err = Register(map[string]interface{}{"sender": senders})
+	assert.Error(err, "no address")
+
+	_, err = Register(map[string]interface{}{
+		"tcp_address": "127.0.0.1:0",
+		"sender":      []interface{}{map[string]interface{}{"name": "north"}},
+	})
+	assert.Error(err, "no key")
+
+	_, err = Register(map[string]interface{}{
+		"tcp_address": "127.0.0.1:0",
+		"sender":      []interface{}{map[string]interface{}{"name": "north", "key": "secret", "auth": "plain"}},
+	})
+	assert.Error(err, "unknown auth")
+
+	_, err = Register(map[string]interface{}{
+		"tcp_address": "127.0.0.1:0",
+		"max_age":     "5x",
+		"sender":      senders,
+	})
+	assert.Error(err)
+
+	in, err := Register(map[string]interface{}{
+		"udp_address": "127.0.0.1:0",
+		"sender":      senders,
+	})
+	assert.NoError(err)
+	r := in.(*Receiver)
+	assert.Equal(maxAgeDefault, r.maxAge)
+	assert.Equal(push.AuthHMAC, r.senders["north"].auth)
+	r.Start(nil, &testSink{})
+	r.Close()
+}
+
+func TestReceive(t *testing.T) {
+	assert := assert.New(t)
+
+	in, err := Register(map[string]interface{}{
+		"tcp_address": "127.0.0.1:0",
+		"udp_address": "127.0.0.1:0",
+		"sender": []interface{}{
+			map[string]interface{}{"name": "north", "key": "secret"},
+			map[string]interface{}{"name": "south", "key": "psk", "auth": "psk"},
+		},
+	})
+	assert.NoError(err)
+	r := in.(*Receiver)
+	sink := &testSink{}
+	r.Start(nil, sink)
+	defer r.Close()
+
+	north, err := respondd.Connect(map[string]interface{}{
+		"type":    "tcp",
+		"address": r.tcp.Addr().String(),
+		"name":    "north",
+		"key":     "secret",
+	})
+	assert.NoError(err)
+	defer north.Close()
+	north.InsertNode(testNode("f81a67a601ea"))
+	north.InsertNode(testNode("f81a67a601eb"))
+
+	south, err := respondd.Connect(map[string]interface{}{
+		"type":    "udp",
+		"address": r.udp.LocalAddr().String(),
+		"name":    "south",
+		"auth":    "psk",
+		"key":     "psk",
+	})
+	assert.NoError(err)
+	defer south.Close()
+	south.InsertNode(testNode("f81a67a601ec"))
+
+	for i := 0; i < 500 && sink.count() < 3; i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	sink.Lock()
+	defer sink.Unlock()
+	sources := make(map[string]string)
+	for i, res := range sink.responses {
+		sources[res.NodeInfo.NodeID] = sink.sources[i]
+		assert.EqualValues(42, res.Statistics.Clients.Total)
+	}
+	assert.Equal(map[string]string{
+		"f81a67a601ea": "north",
+		"f81a67a601eb": "north",
+		"f81a67a601ec": "south",
+	}, sources)
+}
+
+func TestHandleRejected(t *testing.T) {
+	assert := assert.New(t)
+
+	sink := &testSink{}
+	r := &Receiver{
+		senders: map[string]sender{"north": {auth: push.AuthHMAC, key: []byte("secret")}},
+		maxAge:  time.Minute,
+		replays: newReplayCache(),
+		sink:    sink,
+	}
+
+	frame, _ := push.Encode("north", push.AuthHMAC, []byte("wrong"), []byte{}, time.Now())
+	assert.Error(r.handle(frame), "invalid signature")
+
+	frame, _ = push.Encode("west", push.AuthHMAC, []byte("secret"), []byte{}, time.Now())
+	assert.Error(r.handle(frame), "unknown sender")
+
+	frame, _ = push.Encode("north", push.AuthHMAC, []byte("secret"), []byte{}, time.Now().Add(-time.Hour))
+	assert.Error(r.handle(frame), "replay")
+
+	frame, _ = push.Encode("north", push.AuthHMAC, []byte("secret"), []byte("no deflate"), time.Now())
+	assert.Error(r.handle(frame))
+
+	assert.Error(r.handle([]byte("garbage")))
+	assert.Equal(0, sink.count())
+}
+
+func deflated(assert *assert.Assertions, payload []byte) []byte {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	assert.NoError(err)
+	_, err = writer.Write(payload)
+	assert.NoError(err)
+	assert.NoError(writer.Close())
+	return buf.Bytes()
+}
+
+func TestHandleReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	sink := &testSink{}
+	r := &Receiver{
+		senders: map[string]sender{"north": {auth: push.AuthHMAC, key: []byte("secret")}},
+		maxAge:  time.Minute,
+		replays: newReplayCache(),
+		sink:    sink,
+	}
+
+	payload := deflated(assert, []byte(`{"nodeinfo":{"node_id":"f81a67a601ea"}}`))
+	now := time.Now()
+	frame, _ := push.Encode("north", push.AuthHMAC, []byte("secret"), payload, now)
+	assert.NoError(r.handle(frame))
+	assert.Error(r.handle(frame), "replay")
+
+	// same data in the next second
+	frame, _ = push.Encode("north", push.AuthHMAC, []byte("secret"), payload, now.Add(time.Second))
+	assert.NoError(r.handle(frame))
+	assert.Equal(2, sink.count())
+
+	// forgotten after max_age
+	cache := newReplayCache()
+	old, _ := push.Decode(frame)
+	assert.True(cache.add(old, time.Minute, now))
+	assert.False(cache.add(old, time.Minute, now.Add(time.Minute)))
+	assert.True(cache.add(old, time.Minute, now.Add(2*time.Minute)))
+	assert.Len(cache.seen, 1)
+}
+
+func TestHandleTooBig(t *testing.T) {
+	assert := assert.New(t)
+
+	sink := &testSink{}
+	r := &Receiver{
+		senders: map[string]sender{"north": {auth: push.AuthHMAC, key: []byte("secret")}},
+		maxAge:  time.Minute,
+		replays: newReplayCache(),
+		sink:    sink,
+	}
+
+	payload := deflated(assert, bytes.Repeat([]byte(" "), maxPayloadSize+1))
+	assert.True(len(payload) < push.MaxFrameSize)
+	frame, _ := push.Encode("north", push.AuthHMAC, []byte("secret"), payload, time.Now())
+	assert.Error(r.handle(frame))
+	assert.Equal(0, sink.count())
+}
+
+func TestIdleTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{
+		"tcp_address":  "127.0.0.1:0",
+		"idle_timeout": "5x",
+		"sender":       []interface{}{map[string]interface{}{"name": "north", "key": "secret"}},
+	})
+	assert.Error(err)
+
+	in, err := Register(map[string]interface{}{
+		"tcp_address":  "127.0.0.1:0",
+		"idle_timeout": "1s",
+		"sender":       []interface{}{map[string]interface{}{"name": "north", "key": "secret"}},
+	})
+	assert.NoError(err)
+	r := in.(*Receiver)
+	r.Start(nil, &testSink{})
+	defer r.Close()
+
+	conn, err := net.Dial("tcp", r.tcp.Addr().String())
+	assert.NoError(err)
+	defer conn.Close()
+
+	// the receiver closes the idle connection
+	assert.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Equal(io.EOF, err)
+}
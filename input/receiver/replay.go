@@ -0,0 +1,45 @@
+package receiver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/lib/push"
+)
+
+// replayCache remembers the signatures of the accepted frames as long as their timestamp is valid,
+// so a captured frame is not accepted again within max_age
+type replayCache struct {
+	seen      map[string]time.Time // expiry by sender and signature
+	lastPrune time.Time
+	sync.Mutex
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{seen: make(map[string]time.Time)}
+}
+
+// add returns false if the frame was already received
+func (c *replayCache) add(frame *push.Frame, maxAge time.Duration, now time.Time) bool {
+	if maxAge <= 0 {
+		// the frames are valid forever, keep them as long as the default
+		maxAge = maxAgeDefault
+	}
+	key := frame.Name + "\x00" + string(frame.Tag)
+
+	c.Lock()
+	defer c.Unlock()
+	if now.Sub(c.lastPrune) > time.Second {
+		for seenKey, expiry := range c.seen {
+			if now.After(expiry) {
+				delete(c.seen, seenKey)
+			}
+		}
+		c.lastPrune = now
+	}
+	if expiry, ok := c.seen[key]; ok && !now.After(expiry) {
+		return false
+	}
+	c.seen[key] = frame.Timestamp.Add(maxAge)
+	return true
+}
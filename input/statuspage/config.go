@@ -0,0 +1,98 @@
+package statuspage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+const (
+	sourceDefault   = "statuspage"
+	intervalDefault = time.Minute * 5
+	timeoutDefault  = time.Second * 10
+	workersDefault  = 4
+)
+
+// default paths of the sections on the status page of gluon
+var pathsDefault = map[string]string{
+	data.SectionNodeInfo:   "/cgi-bin/nodeinfo",
+	data.SectionStatistics: "/cgi-bin/dyn/statistics",
+}
+
+type Config map[string]interface{}
+
+// Source is the label of the updated nodes
+func (c Config) Source() string {
+	if source, ok := c["source"].(string); ok && source != "" {
+		return source
+	}
+	return sourceDefault
+}
+
+// Interval is how often the nodes are polled
+func (c Config) Interval() (time.Duration, error) {
+	return c.duration("interval", intervalDefault)
+}
+
+// Timeout of a request
+func (c Config) Timeout() (time.Duration, error) {
+	return c.duration("timeout", timeoutDefault)
+}
+
+// Workers is the count of parallel requests
+func (c Config) Workers() int {
+	if workers, ok := c["workers"].(int64); ok && workers > 0 {
+		return int(workers)
+	}
+	return workersDefault
+}
+
+// Addresses are addresses, hostnames or URLs of status pages
+func (c Config) Addresses() []string {
+	var addresses []string
+	if list, ok := c["addresses"].([]interface{}); ok {
+		for _, address := range list {
+			if address, ok := address.(string); ok {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
+
+// KnownNodes polls also known nodes with a status page which were not seen within the interval
+func (c Config) KnownNodes() bool {
+	if known, ok := c["known_nodes"].(bool); ok {
+		return known
+	}
+	return false
+}
+
+// Paths is the path of every section to request
+func (c Config) Paths() map[string]string {
+	paths, ok := c["paths"].(map[string]interface{})
+	if !ok || len(paths) == 0 {
+		return pathsDefault
+	}
+	result := make(map[string]string, len(paths))
+	for section, path := range paths {
+		if path, ok := path.(string); ok {
+			result[section] = path
+		}
+	}
+	return result
+}
+
+func (c Config) duration(key string, def time.Duration) (time.Duration, error) {
+	value, ok := c[key].(string)
+	if !ok || value == "" {
+		return def, nil
+	}
+	var d duration.Duration
+	if err := d.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", key, err)
+	}
+	return d.Duration, nil
+}
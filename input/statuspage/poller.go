@@ -0,0 +1,256 @@
+// Package statuspage polls the HTTP status page API of gluon nodes,
+// e.g. in segments where the respondd multicast is filtered
+package statuspage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/input"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Poller requests the sections of the status pages periodically and pushes them like responses of respondd
+type Poller struct {
+	input.Input
+	source     string
+	interval   time.Duration
+	workers    int
+	addresses  []string
+	knownNodes bool
+	paths      map[string]string
+	client     *http.Client
+
+	nodes *runtime.Nodes
+	sink  input.Sink
+	stop  chan interface{}
+	wg    sync.WaitGroup
+}
+
+// target is a status page to poll
+type target struct {
+	url    string // base URL without path
+	nodeID string // expected NodeID, empty for configured addresses
+}
+
+func init() {
+	input.RegisterAdapter("statuspage", Register)
+}
+
+func Register(configuration map[string]interface{}) (input.Input, error) {
+	var config Config
+	config = configuration
+
+	interval, err := config.Interval()
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := config.Timeout()
+	if err != nil {
+		return nil, err
+	}
+	p := &Poller{
+		source:     config.Source(),
+		interval:   interval,
+		workers:    config.Workers(),
+		addresses:  config.Addresses(),
+		knownNodes: config.KnownNodes(),
+		paths:      config.Paths(),
+		client:     &http.Client{Timeout: timeout},
+		stop:       make(chan interface{}),
+	}
+	if len(p.addresses) == 0 && !p.knownNodes {
+		return nil, errors.New("no addresses given and known_nodes disabled")
+	}
+	return p, nil
+}
+
+// Start polls immediately and then every interval
+func (p *Poller) Start(nodes *runtime.Nodes, sink input.Sink) {
+	p.nodes = nodes
+	p.sink = sink
+	p.wg.Add(1)
+	go p.worker()
+}
+
+// Close stops polling and waits for the running requests
+func (p *Poller) Close() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+func (p *Poller) worker() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.pollOnce()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.pollOnce()
+		}
+	}
+}
+
+// targets returns the configured addresses and the known nodes with a status page,
+// which were not seen within the interval
+func (p *Poller) targets(now time.Time) []target {
+	var targets []target
+	for _, address := range p.addresses {
+		targets = append(targets, target{url: baseURL(address)})
+	}
+	if !p.knownNodes {
+		return targets
+	}
+
+	seenAfter := now.Add(-p.interval)
+	p.nodes.RLock()
+	defer p.nodes.RUnlock()
+	for nodeID, node := range p.nodes.List {
+		if node.Address == nil || node.Nodeinfo == nil || node.Nodeinfo.Software.StatusPage.API < 1 {
+			continue
+		}
+		if node.Lastseen.GetTime().After(seenAfter) {
+			continue
+		}
+		address := node.Address.IP.String()
+		if node.Address.Zone != "" {
+			address += "%" + node.Address.Zone
+		}
+		targets = append(targets, target{url: baseURL(address), nodeID: nodeID})
+	}
+	return targets
+}
+
+// pollOnce polls all targets with the configured count of workers
+func (p *Poller) pollOnce() {
+	targets := p.targets(time.Now())
+	jobs := make(chan target)
+	var polled uint64
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				if err := p.poll(t); err != nil {
+					log.Printf("[statuspage] unable to poll %s: %s", t.url, err)
+				} else {
+					atomic.AddUint64(&polled, 1)
+				}
+			}
+		}()
+	}
+
+loop:
+	for _, t := range targets {
+		select {
+		case jobs <- t:
+		case <-p.stop:
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	log.Printf("[statuspage] polled %d of %d status pages", polled, len(targets))
+}
+
+// poll requests the status page and pushes the node
+func (p *Poller) poll(t target) error {
+	res, err := p.fetch(t.url)
+	if err != nil {
+		return err
+	}
+	if nodeID := res.NodeID(); t.nodeID != "" && t.nodeID != nodeID {
+		return fmt.Errorf("answered with NodeID '%s' instead of %s", nodeID, t.nodeID)
+	}
+	return p.sink.Push(p.source, res)
+}
+
+// fetch requests all sections of the status page
+func (p *Poller) fetch(base string) (*data.ResponseData, error) {
+	sections := make(map[string]json.RawMessage)
+	for section, path := range p.paths {
+		raw, err := p.get(base + path)
+		if err != nil {
+			return nil, err
+		}
+		sections[section] = raw
+	}
+
+	// decode the sections like a respondd response
+	raw, err := json.Marshal(sections)
+	if err != nil {
+		return nil, err
+	}
+	res := &data.ResponseData{}
+	if err = json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// get requests a JSON object, of an event stream the data of the first event is used
+func (p *Poller) get(url string) (json.RawMessage, error) {
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		for {
+			line, err := reader.ReadString('\n')
+			if strings.HasPrefix(line, "data:") {
+				return json.RawMessage(strings.TrimSpace(line[len("data:"):])), nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: no event received: %s", url, err)
+			}
+		}
+	}
+
+	var raw json.RawMessage
+	err = json.NewDecoder(reader).Decode(&raw)
+	return raw, err
+}
+
+// baseURL returns the URL of the status page of an address, hostname or URL
+func baseURL(address string) string {
+	if strings.Contains(address, "://") {
+		return strings.TrimSuffix(address, "/")
+	}
+
+	host := address
+	ip, zone := address, ""
+	if i := strings.LastIndex(address, "%"); i >= 0 {
+		ip, zone = address[:i], address[i+1:]
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		host = "[" + parsed.String()
+		if zone != "" {
+			host += "%25" + url.PathEscape(zone)
+		}
+		host += "]"
+	}
+	return "http://" + host
+}
@@ -0,0 +1,160 @@
+package statuspage
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+type testSink struct {
+	sync.Mutex
+	sources   []string
+	responses []*data.ResponseData
+}
+
+func (s *testSink) Push(source string, res *data.ResponseData) error {
+	s.Lock()
+	defer s.Unlock()
+	s.sources = append(s.sources, source)
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func (s *testSink) Merge(source string, node *runtime.FederatedNode) error {
+	return nil
+}
+
+// statusPage returns a fake status page of gluon
+func statusPage(nodeID string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cgi-bin/nodeinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"node_id":"%s","hostname":"status-page","software":{"status-page":{"api":1}}}`, nodeID)
+	})
+	mux.HandleFunc("/cgi-bin/dyn/statistics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: statistics\ndata: {\"node_id\":\"%s\",\"clients\":{\"total\":3}}\n\n", nodeID)
+		w.(http.Flusher).Flush()
+		// the event stream stays open
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second * 5):
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestPoll(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := statusPage("f81a67a601ea")
+	defer srv.Close()
+
+	sink := &testSink{}
+	in, err := Register(map[string]interface{}{
+		"addresses": []interface{}{srv.URL},
+		"source":    "segment-a",
+	})
+	assert.NoError(err)
+	p := in.(*Poller)
+	p.sink = sink
+	p.pollOnce()
+
+	assert.Equal([]string{"segment-a"}, sink.sources)
+	res := sink.responses[0]
+	assert.Equal("f81a67a601ea", res.NodeInfo.NodeID)
+	assert.Equal("status-page", res.NodeInfo.Hostname)
+	assert.EqualValues(3, res.Statistics.Clients.Total)
+
+	// a known node has to answer with its NodeID
+	err = p.poll(target{url: srv.URL, nodeID: "f81a67a601eb"})
+	assert.Error(err)
+
+	// missing sections
+	err = p.poll(target{url: srv.URL + "/missing"})
+	assert.Error(err)
+}
+
+func TestTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	now := jsontime.Now()
+	withStatusPage := &data.NodeInfo{NodeID: "f81a67a601ea"}
+	withStatusPage.Software.StatusPage.API = 1
+	nodes.List["f81a67a601ea"] = &runtime.Node{
+		Lastseen: now.Add(-time.Hour),
+		Address:  &net.UDPAddr{IP: net.ParseIP("fe80::1"), Zone: "br-ffhb"},
+		Nodeinfo: withStatusPage,
+	}
+	nodes.List["f81a67a601eb"] = &runtime.Node{ // seen recently
+		Lastseen: now,
+		Address:  &net.UDPAddr{IP: net.ParseIP("fe80::2"), Zone: "br-ffhb"},
+		Nodeinfo: withStatusPage,
+	}
+	nodes.List["f81a67a601ec"] = &runtime.Node{ // without status page
+		Lastseen: now.Add(-time.Hour),
+		Address:  &net.UDPAddr{IP: net.ParseIP("fe80::3"), Zone: "br-ffhb"},
+		Nodeinfo: &data.NodeInfo{NodeID: "f81a67a601ec"},
+	}
+
+	in, err := Register(map[string]interface{}{"addresses": []interface{}{"10.0.0.1"}})
+	assert.NoError(err)
+	p := in.(*Poller)
+	p.nodes = nodes
+	assert.Equal([]target{{url: "http://10.0.0.1"}}, p.targets(now.GetTime()))
+
+	p.knownNodes = true
+	assert.Equal([]target{
+		{url: "http://10.0.0.1"},
+		{url: "http://[fe80::1%25br-ffhb]", nodeID: "f81a67a601ea"},
+	}, p.targets(now.GetTime()))
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Register(map[string]interface{}{})
+	assert.Error(err, "nothing to poll")
+
+	_, err = Register(map[string]interface{}{"known_nodes": true, "interval": "5x"})
+	assert.Error(err)
+
+	in, err := Register(map[string]interface{}{
+		"known_nodes": true,
+		"interval":    "1m",
+		"workers":     int64(2),
+		"paths":       map[string]interface{}{"nodeinfo": "/nodeinfo.json"},
+	})
+	assert.NoError(err)
+	p := in.(*Poller)
+	assert.Equal(time.Minute, p.interval)
+	assert.Equal(timeoutDefault, p.client.Timeout)
+	assert.Equal(2, p.workers)
+	assert.Equal(sourceDefault, p.source)
+	assert.Equal(map[string]string{"nodeinfo": "/nodeinfo.json"}, p.paths)
+
+	// start and close
+	p.Start(runtime.NewNodes(&runtime.NodesConfig{}), &testSink{})
+	p.Close()
+}
+
+func TestBaseURL(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("http://10.0.0.1", baseURL("10.0.0.1"))
+	assert.Equal("http://[2001:db8::1]", baseURL("2001:db8::1"))
+	assert.Equal("http://[fe80::1%25br-ffhb]", baseURL("fe80::1%br-ffhb"))
+	assert.Equal("http://node.example.org", baseURL("node.example.org"))
+	assert.Equal("https://node.example.org:8443", baseURL("https://node.example.org:8443/"))
+}
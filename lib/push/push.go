@@ -0,0 +1,189 @@
+// Package push implements the authenticated frames, which are used to push
+// deflated respondd data from one yanic instance to another
+package push
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Version of the frame format
+const Version = 1
+
+// MaxFrameSize is the maximum size of a frame
+const MaxFrameSize = 1 << 20
+
+// Auth is the method to authenticate a sender
+type Auth string
+
+const (
+	// AuthHMAC signs every frame with a HMAC-SHA256 of the key
+	AuthHMAC Auth = "hmac"
+	// AuthPSK sends the pre-shared key within every frame
+	AuthPSK Auth = "psk"
+)
+
+// Frame is a pushed payload of a sender
+//
+// Layout: version (1 byte), length of name (1 byte), name,
+// length of tag (1 byte), tag, unix timestamp (8 bytes), payload
+type Frame struct {
+	Name      string
+	Tag       []byte
+	Timestamp time.Time
+	Payload   []byte
+}
+
+// ParseAuth returns the method by its name, an empty name is HMAC
+func ParseAuth(name string) (Auth, error) {
+	switch Auth(name) {
+	case "", AuthHMAC:
+		return AuthHMAC, nil
+	case AuthPSK:
+		return AuthPSK, nil
+	}
+	return "", fmt.Errorf("unknown auth '%s'", name)
+}
+
+// Encode creates the frame of a payload
+func Encode(name string, auth Auth, key []byte, payload []byte, now time.Time) ([]byte, error) {
+	if len(name) == 0 || len(name) > 255 {
+		return nil, errors.New("name needs 1 to 255 bytes")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("no key given")
+	}
+
+	var tagLen int
+	switch auth {
+	case AuthHMAC:
+		tagLen = sha256.Size
+	case AuthPSK:
+		if len(key) > 255 {
+			return nil, errors.New("pre-shared key has more than 255 bytes")
+		}
+		tagLen = len(key)
+	default:
+		return nil, fmt.Errorf("unknown auth '%s'", auth)
+	}
+
+	frame := make([]byte, 0, 3+len(name)+tagLen+8+len(payload))
+	frame = append(frame, Version, byte(len(name)))
+	frame = append(frame, name...)
+	frame = append(frame, byte(tagLen))
+	tagAt := len(frame)
+	frame = append(frame, make([]byte, tagLen)...)
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(now.Unix()))
+	frame = append(frame, timestamp[:]...)
+	frame = append(frame, payload...)
+
+	if len(frame) > MaxFrameSize {
+		return nil, fmt.Errorf("frame with %d bytes is too big", len(frame))
+	}
+
+	if auth == AuthHMAC {
+		copy(frame[tagAt:], sign(key, name, timestamp[:], payload))
+	} else {
+		copy(frame[tagAt:], key)
+	}
+	return frame, nil
+}
+
+// Decode parses a frame without verifying it
+func Decode(raw []byte) (*Frame, error) {
+	if len(raw) < 2 || raw[0] != Version {
+		return nil, errors.New("unknown frame version")
+	}
+	pos := 2
+	nameLen := int(raw[1])
+	if len(raw) < pos+nameLen+1 {
+		return nil, errors.New("frame too short")
+	}
+	name := string(raw[pos : pos+nameLen])
+	pos += nameLen
+
+	tagLen := int(raw[pos])
+	pos++
+	if len(raw) < pos+tagLen+8 {
+		return nil, errors.New("frame too short")
+	}
+	tag := raw[pos : pos+tagLen]
+	pos += tagLen
+
+	timestamp := int64(binary.BigEndian.Uint64(raw[pos : pos+8]))
+	pos += 8
+
+	return &Frame{
+		Name:      name,
+		Tag:       tag,
+		Timestamp: time.Unix(timestamp, 0),
+		Payload:   raw[pos:],
+	}, nil
+}
+
+// Verify checks the tag of the frame with the key of its sender
+// and rejects frames with a timestamp older or newer than maxAge (only by HMAC)
+func (f *Frame) Verify(auth Auth, key []byte, now time.Time, maxAge time.Duration) error {
+	switch auth {
+	case AuthHMAC:
+		var timestamp [8]byte
+		binary.BigEndian.PutUint64(timestamp[:], uint64(f.Timestamp.Unix()))
+		if !hmac.Equal(f.Tag, sign(key, f.Name, timestamp[:], f.Payload)) {
+			return fmt.Errorf("invalid signature of %s", f.Name)
+		}
+		if age := now.Sub(f.Timestamp); maxAge > 0 && (age > maxAge || age < -maxAge) {
+			return fmt.Errorf("frame of %s with timestamp %s is outdated", f.Name, f.Timestamp)
+		}
+	case AuthPSK:
+		if subtle.ConstantTimeCompare(f.Tag, key) != 1 {
+			return fmt.Errorf("invalid key of %s", f.Name)
+		}
+	default:
+		return fmt.Errorf("unknown auth '%s'", auth)
+	}
+	return nil
+}
+
+// sign returns the HMAC of the authenticated parts of a frame
+func sign(key []byte, name string, timestamp []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte{Version, byte(len(name))})
+	mac.Write([]byte(name))
+	mac.Write(timestamp)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// WriteFrame writes a frame with its length to a stream (e.g. TCP)
+func WriteFrame(w io.Writer, frame []byte) error {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(frame)))
+	if _, err := w.Write(append(length[:], frame...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadFrame reads a frame with its length of a stream
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > MaxFrameSize {
+		return nil, fmt.Errorf("frame with %d bytes is too big", size)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
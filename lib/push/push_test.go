@@ -0,0 +1,93 @@
+package push
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameHMAC(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Unix(1500000000, 0)
+	key := []byte("secret")
+
+	raw, err := Encode("region-north", AuthHMAC, key, []byte("payload"), now)
+	assert.NoError(err)
+
+	frame, err := Decode(raw)
+	assert.NoError(err)
+	assert.Equal("region-north", frame.Name)
+	assert.Equal([]byte("payload"), frame.Payload)
+	assert.Equal(now, frame.Timestamp)
+
+	assert.NoError(frame.Verify(AuthHMAC, key, now.Add(time.Minute), time.Minute*5))
+	assert.Error(frame.Verify(AuthHMAC, []byte("wrong"), now, time.Minute*5))
+	assert.Error(frame.Verify(AuthHMAC, key, now.Add(time.Hour), time.Minute*5), "outdated")
+	assert.Error(frame.Verify(AuthPSK, key, now, time.Minute*5))
+
+	// manipulated payload
+	raw[len(raw)-1] = 'X'
+	frame, _ = Decode(raw)
+	assert.Error(frame.Verify(AuthHMAC, key, now, time.Minute*5))
+}
+
+func TestFramePSK(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+
+	raw, err := Encode("region-south", AuthPSK, []byte("secret"), []byte("payload"), now)
+	assert.NoError(err)
+
+	frame, err := Decode(raw)
+	assert.NoError(err)
+	assert.NoError(frame.Verify(AuthPSK, []byte("secret"), now.Add(time.Hour), time.Minute))
+	assert.Error(frame.Verify(AuthPSK, []byte("secreT"), now, time.Minute))
+	assert.Error(frame.Verify(AuthHMAC, []byte("secret"), now, time.Minute))
+}
+
+func TestFrameInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := Encode("", AuthHMAC, []byte("secret"), nil, time.Now())
+	assert.Error(err)
+	_, err = Encode("name", AuthHMAC, nil, nil, time.Now())
+	assert.Error(err)
+	_, err = Encode("name", Auth("plain"), []byte("secret"), nil, time.Now())
+	assert.Error(err)
+
+	_, err = Decode(nil)
+	assert.Error(err)
+	_, err = Decode([]byte{Version, 10, 'a'})
+	assert.Error(err)
+	_, err = Decode([]byte{Version, 1, 'a', 32, 0})
+	assert.Error(err)
+
+	auth, err := ParseAuth("")
+	assert.NoError(err)
+	assert.Equal(AuthHMAC, auth)
+	_, err = ParseAuth("plain")
+	assert.Error(err)
+}
+
+func TestStream(t *testing.T) {
+	assert := assert.New(t)
+
+	var stream bytes.Buffer
+	assert.NoError(WriteFrame(&stream, []byte("first")))
+	assert.NoError(WriteFrame(&stream, []byte("second")))
+
+	frame, err := ReadFrame(&stream)
+	assert.NoError(err)
+	assert.Equal([]byte("first"), frame)
+	frame, err = ReadFrame(&stream)
+	assert.NoError(err)
+	assert.Equal([]byte("second"), frame)
+	_, err = ReadFrame(&stream)
+	assert.Error(err)
+
+	stream.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	_, err = ReadFrame(&stream)
+	assert.Error(err, "too big")
+}
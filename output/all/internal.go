@@ -17,10 +17,27 @@ func Start(nodes *runtime.Nodes, config runtime.NodesConfig) (err error) {
 	if err != nil {
 		return
 	}
+	start(nodes, config.SaveInterval.Duration)
+	return
+}
+
+// Reload replaces the running outputs by the given ones (created by Register)
+func Reload(nodes *runtime.Nodes, config runtime.NodesConfig, o output.Output) {
+	Close()
+	outputA = o
+	start(nodes, config.SaveInterval.Duration)
+}
+
+func start(nodes *runtime.Nodes, saveInterval time.Duration) {
 	quit = make(chan struct{})
 	wg.Add(1)
-	go saveWorker(nodes, config.SaveInterval.Duration)
-	return
+	go saveWorker(nodes, saveInterval)
+}
+
+// Save saves the nodes once to the outputs of the last Start or Reload,
+// it should not run while the outputs are started
+func Save(nodes *runtime.Nodes) {
+	outputA.Save(nodes)
 }
 
 func Close() {
@@ -1,7 +1,10 @@
 package all
 
 import (
+	_ "github.com/FreifunkBremen/yanic/output/federation"
+	_ "github.com/FreifunkBremen/yanic/output/history"
 	_ "github.com/FreifunkBremen/yanic/output/meshviewer"
 	_ "github.com/FreifunkBremen/yanic/output/meshviewer-ffrgb"
 	_ "github.com/FreifunkBremen/yanic/output/nodelist"
+	_ "github.com/FreifunkBremen/yanic/output/quarantine"
 )
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/FreifunkBremen/yanic/output"
 	"github.com/FreifunkBremen/yanic/runtime"
@@ -119,3 +120,20 @@ func TestStart(t *testing.T) {
 	})
 	assert.Error(err)
 }
+
+func TestReload(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := &runtime.Nodes{}
+	config := runtime.NodesConfig{}
+	config.SaveInterval.Duration = time.Millisecond
+
+	assert.NoError(Start(nodes, config))
+
+	newOutput := &testOutput{}
+	Reload(nodes, config, newOutput)
+	assert.Equal(newOutput, outputA)
+	time.Sleep(time.Millisecond * 10)
+	Close()
+	assert.NotEqual(0, newOutput.Get())
+}
@@ -0,0 +1,42 @@
+package federation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	out, err := Register(map[string]interface{}{})
+	assert.Error(err)
+	assert.Nil(out)
+
+	out, err = Register(map[string]interface{}{
+		"path": "/tmp/federation.json",
+	})
+	os.Remove("/tmp/federation.json")
+	assert.NoError(err)
+	assert.NotNil(out)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	nodes.AddNode(&runtime.Node{
+		Nodeinfo:   &data.NodeInfo{NodeID: "f81a67a601ea"},
+		Neighbours: &data.Neighbours{NodeID: "f81a67a601ea"},
+	})
+	out.Save(nodes)
+
+	raw, err := ioutil.ReadFile("/tmp/federation.json")
+	assert.NoError(err)
+	var federated runtime.FederatedNodes
+	assert.NoError(json.Unmarshal(raw, &federated))
+	assert.Len(federated.Nodes, 1)
+	assert.NotNil(federated.Nodes[0].Neighbours)
+}
@@ -43,7 +43,14 @@ func (config *domainAsSite) Apply(node *runtime.Node) *runtime.Node {
 				VPN:      nodeinfo.VPN,
 				Wireless: nodeinfo.Wireless,
 			},
-			Neighbours: node.Neighbours,
+			Neighbours:      node.Neighbours,
+			CustomFields:    node.CustomFields,
+			SectionsUpdated: node.SectionsUpdated,
+			StaleSections:   node.StaleSections,
+			Source:          node.Source,
+			History:         node.History,
+			Override:        node.Override,
+			Reported:        node.Reported,
 		}
 	}
 	return node
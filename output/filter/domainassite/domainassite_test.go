@@ -28,6 +28,24 @@ func TestFilter(t *testing.T) {
 	assert.Equal("city", n.Nodeinfo.System.SiteCode)
 	assert.Equal("", n.Nodeinfo.System.DomainCode)
 
+	// keep the history of the node
+	history := &runtime.History{}
+	n = filter.Apply(&runtime.Node{
+		Nodeinfo: &data.NodeInfo{System: data.System{DomainCode: "city"}},
+		History:  history,
+	})
+	assert.Equal(history, n.History)
+
+	// keep the override and the reported nodeinfo
+	reported := &data.NodeInfo{Hostname: "reported"}
+	n = filter.Apply(&runtime.Node{
+		Nodeinfo: &data.NodeInfo{System: data.System{DomainCode: "city"}},
+		Reported: reported,
+		Override: &runtime.Override{Tags: map[string]string{"room": "attic"}},
+	})
+	assert.Equal(map[string]string{"room": "attic"}, n.Tags())
+	assert.Equal(reported, n.Reported)
+
 	// keep owner configuration
 	filter, _ = build(false)
 	n = filter.Apply(&runtime.Node{Nodeinfo: &data.NodeInfo{
@@ -69,5 +69,15 @@ func (set Set) Apply(nodesOrigin *runtime.Nodes) *runtime.Nodes {
 			nodes.AddNode(node)
 		}
 	}
+
+	// keep the rejected responses of unknown nodes and of the nodes which passed the filters
+	for _, entry := range nodesOrigin.Quarantined {
+		if _, known := nodesOrigin.List[entry.NodeID]; known {
+			if _, ok := nodes.List[entry.NodeID]; !ok {
+				continue
+			}
+		}
+		nodes.Quarantined = append(nodes.Quarantined, entry)
+	}
 	return nodes
 }
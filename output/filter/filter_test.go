@@ -71,6 +71,7 @@ func TestFilter(t *testing.T) {
 				Nodeinfo: &data.NodeInfo{NodeID: "a"},
 			},
 		},
+		Quarantined: []*runtime.QuarantineEntry{{NodeID: "a"}, {NodeID: "b"}},
 	}
 	filter, err = New(map[string]interface{}{
 		"test": false,
@@ -78,6 +79,9 @@ func TestFilter(t *testing.T) {
 	assert.Len(err, 0)
 	nodes = filter.Apply(nodes)
 	assert.Len(nodes.List, 0)
+	// only the rejected responses of the unknown node
+	assert.Len(nodes.Quarantined, 1)
+	assert.Equal("b", nodes.Quarantined[0].NodeID)
 
 	// keep a node
 	nodes = &runtime.Nodes{
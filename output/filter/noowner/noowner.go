@@ -23,6 +23,19 @@ func build(config interface{}) (filter.Filter, error) {
 
 func (no *noowner) Apply(node *runtime.Node) *runtime.Node {
 	if nodeinfo := node.Nodeinfo; nodeinfo != nil && no.has {
+		// the owner is removed of the reported nodeinfo and the override as well
+		reported := node.Reported
+		if reported != nil {
+			copied := *reported
+			copied.Owner = nil
+			reported = &copied
+		}
+		override := node.Override
+		if override != nil && override.Owner != "" {
+			copied := *override
+			copied.Owner = ""
+			override = &copied
+		}
 		node = &runtime.Node{
 			Address:    node.Address,
 			Firstseen:  node.Firstseen,
@@ -41,7 +54,14 @@ func (no *noowner) Apply(node *runtime.Node) *runtime.Node {
 				VPN:      nodeinfo.VPN,
 				Wireless: nodeinfo.Wireless,
 			},
-			Neighbours: node.Neighbours,
+			Neighbours:      node.Neighbours,
+			CustomFields:    node.CustomFields,
+			SectionsUpdated: node.SectionsUpdated,
+			StaleSections:   node.StaleSections,
+			Source:          node.Source,
+			History:         node.History,
+			Override:        override,
+			Reported:        reported,
 		}
 	}
 	return node
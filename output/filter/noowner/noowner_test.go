@@ -26,6 +26,21 @@ func TestFilter(t *testing.T) {
 	assert.NotNil(n)
 	assert.Nil(n.Nodeinfo.Owner)
 
+	// keep the history of the node
+	history := &runtime.History{}
+	n = filter.Apply(&runtime.Node{Nodeinfo: &data.NodeInfo{}, History: history})
+	assert.Equal(history, n.History)
+
+	// keep the tags of the override, without the owner
+	n = filter.Apply(&runtime.Node{
+		Nodeinfo: &data.NodeInfo{Owner: &data.Owner{Contact: "admin"}},
+		Reported: &data.NodeInfo{Owner: &data.Owner{Contact: "blub"}},
+		Override: &runtime.Override{Owner: "admin", Tags: map[string]string{"room": "attic"}},
+	})
+	assert.Equal(map[string]string{"room": "attic"}, n.Tags())
+	assert.Nil(n.Reported.Owner)
+	assert.Equal("", n.Override.Owner)
+
 	// keep owner configuration
 	filter, _ = build(false)
 	n = filter.Apply(&runtime.Node{Nodeinfo: &data.NodeInfo{
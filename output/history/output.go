@@ -0,0 +1,66 @@
+package history
+
+import (
+	"errors"
+
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// History of the statistics of all nodes (e.g. for sparklines and graphs)
+type History struct {
+	Timestamp jsontime.Time                     `json:"timestamp"` // Timestamp of the generation
+	Nodes     map[string][]runtime.HistoryEntry `json:"nodes"`     // snapshots by node ID, the oldest first
+}
+
+type Output struct {
+	output.Output
+	path string
+}
+
+type Config map[string]interface{}
+
+func (c Config) Path() string {
+	if path, ok := c["path"]; ok {
+		return path.(string)
+	}
+	return ""
+}
+
+func init() {
+	output.RegisterAdapter("history", Register)
+}
+
+func Register(configuration map[string]interface{}) (output.Output, error) {
+	var config Config
+	config = configuration
+
+	if path := config.Path(); path != "" {
+		return &Output{
+			path: path,
+		}, nil
+	}
+	return nil, errors.New("no path given")
+}
+
+// Save writes the history of the nodes, which have one (see history_size of [nodes])
+func (o *Output) Save(nodes *runtime.Nodes) {
+	nodes.RLock()
+	defer nodes.RUnlock()
+
+	runtime.SaveJSON(transform(nodes), o.path)
+}
+
+func transform(nodes *runtime.Nodes) *History {
+	history := &History{
+		Timestamp: jsontime.Now(),
+		Nodes:     make(map[string][]runtime.HistoryEntry),
+	}
+	for nodeID, node := range nodes.List {
+		if node.History != nil && node.History.Len() > 0 {
+			history.Nodes[nodeID] = node.History.Entries()
+		}
+	}
+	return history
+}
@@ -0,0 +1,41 @@
+package history
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	out, err := Register(map[string]interface{}{})
+	assert.Error(err)
+	assert.Nil(out)
+
+	out, err = Register(map[string]interface{}{
+		"path": "/tmp/history.json",
+	})
+	os.Remove("/tmp/history.json")
+	assert.NoError(err)
+	assert.NotNil(out)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{HistorySize: 2})
+	nodes.Update("f81a67a601ea", &data.ResponseData{Statistics: &data.Statistics{Clients: data.Clients{Total: 3}}})
+	nodes.Update("f81a67a601eb", &data.ResponseData{})
+	out.Save(nodes)
+
+	raw, err := ioutil.ReadFile("/tmp/history.json")
+	assert.NoError(err)
+	var history History
+	assert.NoError(json.Unmarshal(raw, &history))
+	assert.Len(history.Nodes, 1)
+	assert.Len(history.Nodes["f81a67a601ea"], 1)
+	assert.Equal(uint32(3), history.Nodes["f81a67a601ea"][0].Clients)
+}
@@ -183,3 +183,17 @@ func TestTransform(t *testing.T) {
 		}
 	}
 }
+
+func TestNodeTags(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	node := NewNode(nodes, &runtime.Node{
+		Nodeinfo: &data.NodeInfo{NodeID: "node_a"},
+		Override: &runtime.Override{Tags: map[string]string{"room": "attic"}},
+	})
+	assert.Equal(map[string]string{"room": "attic"}, node.Tags)
+
+	node = NewNode(nodes, &runtime.Node{Nodeinfo: &data.NodeInfo{NodeID: "node_b"}})
+	assert.Nil(node.Tags)
+}
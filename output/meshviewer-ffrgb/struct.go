@@ -14,34 +14,36 @@ type Meshviewer struct {
 }
 
 type Node struct {
-	Firstseen      jsontime.Time `json:"firstseen"`
-	Lastseen       jsontime.Time `json:"lastseen"`
-	IsOnline       bool          `json:"is_online"`
-	IsGateway      bool          `json:"is_gateway"`
-	Clients        uint32        `json:"clients"`
-	ClientsWifi24  uint32        `json:"clients_wifi24"`
-	ClientsWifi5   uint32        `json:"clients_wifi5"`
-	ClientsOthers  uint32        `json:"clients_other"`
-	RootFSUsage    float64       `json:"rootfs_usage"`
-	LoadAverage    float64       `json:"loadavg"`
-	MemoryUsage    *float64      `json:"memory_usage,omitempty"`
-	Uptime         jsontime.Time `json:"uptime,omitempty"`
-	GatewayNexthop string        `json:"gateway_nexthop,omitempty"`
-	GatewayIPv4    string        `json:"gateway,omitempty"`
-	GatewayIPv6    string        `json:"gateway6,omitempty"`
-	NodeID         string        `json:"node_id"`
-	MAC            string        `json:"mac"`
-	Addresses      []string      `json:"addresses"`
-	SiteCode       string        `json:"site_code,omitempty"`
-	DomainCode     string        `json:"-"`
-	Hostname       string        `json:"hostname"`
-	Owner          string        `json:"owner,omitempty"`
-	Location       *Location     `json:"location,omitempty"`
-	Firmware       Firmware      `json:"firmware,omitempty"`
-	Autoupdater    Autoupdater   `json:"autoupdater"`
-	Nproc          int           `json:"nproc"`
-	Model          string        `json:"model,omitempty"`
-	VPN            bool          `json:"vpn"`
+	Firstseen      jsontime.Time     `json:"firstseen"`
+	Lastseen       jsontime.Time     `json:"lastseen"`
+	IsOnline       bool              `json:"is_online"`
+	IsGateway      bool              `json:"is_gateway"`
+	Clients        uint32            `json:"clients"`
+	ClientsWifi24  uint32            `json:"clients_wifi24"`
+	ClientsWifi5   uint32            `json:"clients_wifi5"`
+	ClientsOthers  uint32            `json:"clients_other"`
+	RootFSUsage    float64           `json:"rootfs_usage"`
+	LoadAverage    float64           `json:"loadavg"`
+	MemoryUsage    *float64          `json:"memory_usage,omitempty"`
+	Uptime         jsontime.Time     `json:"uptime,omitempty"`
+	GatewayNexthop string            `json:"gateway_nexthop,omitempty"`
+	GatewayIPv4    string            `json:"gateway,omitempty"`
+	GatewayIPv6    string            `json:"gateway6,omitempty"`
+	NodeID         string            `json:"node_id"`
+	MAC            string            `json:"mac"`
+	Addresses      []string          `json:"addresses"`
+	SiteCode       string            `json:"site_code,omitempty"`
+	DomainCode     string            `json:"-"`
+	Hostname       string            `json:"hostname"`
+	Owner          string            `json:"owner,omitempty"`
+	Location       *Location         `json:"location,omitempty"`
+	Firmware       Firmware          `json:"firmware,omitempty"`
+	Autoupdater    Autoupdater       `json:"autoupdater"`
+	Nproc          int               `json:"nproc"`
+	Model          string            `json:"model,omitempty"`
+	VPN            bool              `json:"vpn"`
+	StaleSections  []string          `json:"stale_sections,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
 }
 
 // Firmware out of software
@@ -75,10 +77,12 @@ type Link struct {
 
 func NewNode(nodes *runtime.Nodes, n *runtime.Node) *Node {
 	node := &Node{
-		Firstseen: n.Firstseen,
-		Lastseen:  n.Lastseen,
-		IsOnline:  n.Online,
-		IsGateway: n.IsGateway(),
+		Firstseen:     n.Firstseen,
+		Lastseen:      n.Lastseen,
+		IsOnline:      n.Online,
+		IsGateway:     n.IsGateway(),
+		StaleSections: n.StaleSections,
+		Tags:          n.Tags(),
 	}
 
 	if nodeinfo := n.Nodeinfo; nodeinfo != nil {
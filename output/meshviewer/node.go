@@ -13,6 +13,9 @@ type Node struct {
 	Statistics *Statistics      `json:"statistics"`
 	Nodeinfo   *data.NodeInfo   `json:"nodeinfo"`
 	Neighbours *data.Neighbours `json:"-"`
+
+	StaleSections []string          `json:"stale_sections,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
 }
 
 // Flags status of node set by collector for the meshviewer
@@ -33,7 +33,9 @@ func BuildNodesV1(nodes *runtime.Nodes) interface{} {
 				Online:  nodeOrigin.Online,
 				Gateway: nodeOrigin.IsGateway(),
 			},
-			Nodeinfo: nodeOrigin.Nodeinfo,
+			Nodeinfo:      nodeOrigin.Nodeinfo,
+			StaleSections: nodeOrigin.StaleSections,
+			Tags:          nodeOrigin.Tags(),
 		}
 		node.Statistics = NewStatistics(nodeOrigin.Statistics, nodeOrigin.Online)
 		meshviewerNodes.List[nodeID] = node
@@ -31,7 +31,9 @@ func BuildNodesV2(nodes *runtime.Nodes) interface{} {
 				Online:  nodeOrigin.Online,
 				Gateway: nodeOrigin.IsGateway(),
 			},
-			Nodeinfo: nodeOrigin.Nodeinfo,
+			Nodeinfo:      nodeOrigin.Nodeinfo,
+			StaleSections: nodeOrigin.StaleSections,
+			Tags:          nodeOrigin.Tags(),
 		}
 		node.Statistics = NewStatistics(nodeOrigin.Statistics, nodeOrigin.Online)
 		meshviewerNodes.List = append(meshviewerNodes.List, node)
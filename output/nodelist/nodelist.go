@@ -21,6 +21,7 @@ type Node struct {
 		LastContact jsontime.Time `json:"lastcontact"`
 		Clients     uint32        `json:"clients"`
 	} `json:"status"`
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 type Position struct {
@@ -33,6 +34,7 @@ func NewNode(n *runtime.Node) (node *Node) {
 		node = &Node{
 			ID:   nodeinfo.NodeID,
 			Name: nodeinfo.Hostname,
+			Tags: n.Tags(),
 		}
 		if location := nodeinfo.Location; location != nil {
 			node.Position = &Position{Lat: location.Latitude, Long: location.Longitude}
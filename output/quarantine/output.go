@@ -0,0 +1,53 @@
+package quarantine
+
+import (
+	"errors"
+
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/output"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// Quarantine is the list of the latest rejected responses (see allowed_sources and address_guard of [respondd])
+type Quarantine struct {
+	Timestamp jsontime.Time             `json:"timestamp"` // Timestamp of the generation
+	Entries   []runtime.QuarantineEntry `json:"entries"`   // rejected responses, the latest at last
+}
+
+type Output struct {
+	output.Output
+	path string
+}
+
+type Config map[string]interface{}
+
+func (c Config) Path() string {
+	if path, ok := c["path"]; ok {
+		return path.(string)
+	}
+	return ""
+}
+
+func init() {
+	output.RegisterAdapter("quarantine", Register)
+}
+
+func Register(configuration map[string]interface{}) (output.Output, error) {
+	var config Config
+	config = configuration
+
+	if path := config.Path(); path != "" {
+		return &Output{
+			path: path,
+		}, nil
+	}
+	return nil, errors.New("no path given")
+}
+
+// Save writes the rejected responses
+func (o *Output) Save(nodes *runtime.Nodes) {
+	runtime.SaveJSON(&Quarantine{
+		Timestamp: jsontime.Now(),
+		Entries:   nodes.GetQuarantine(),
+	}, o.path)
+}
@@ -0,0 +1,49 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	out, err := Register(map[string]interface{}{})
+	assert.Error(err)
+	assert.Nil(out)
+
+	out, err = Register(map[string]interface{}{
+		"path": "/tmp/quarantine.json",
+	})
+	os.Remove("/tmp/quarantine.json")
+	assert.NoError(err)
+	assert.NotNil(out)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	out.Save(nodes)
+	raw, err := ioutil.ReadFile("/tmp/quarantine.json")
+	assert.NoError(err)
+	assert.Contains(string(raw), `"entries":[]`)
+
+	nodes.Quarantine(runtime.QuarantineEntry{
+		Address: "[fe80::2%bat0]:1001",
+		NodeID:  "f81a67a601ea",
+		Usual:   "[fe80::1%bat0]:1001",
+		Reason:  runtime.RejectAddressChanged,
+	})
+	out.Save(nodes)
+
+	raw, err = ioutil.ReadFile("/tmp/quarantine.json")
+	assert.NoError(err)
+	var quarantine Quarantine
+	assert.NoError(json.Unmarshal(raw, &quarantine))
+	assert.Len(quarantine.Entries, 1)
+	assert.Equal("f81a67a601ea", quarantine.Entries[0].NodeID)
+	assert.Equal(runtime.RejectAddressChanged, quarantine.Entries[0].Reason)
+}
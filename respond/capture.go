@@ -0,0 +1,181 @@
+package respond
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// captureRecord is a received datagram in a capture file
+type captureRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Interface string    `json:"interface,omitempty"`
+	Address   string    `json:"address"`
+	Raw       []byte    `json:"raw"`
+}
+
+// recordBufferSize is the count of datagrams queued for the capture file
+const recordBufferSize = 1024
+
+// recordRequest is a datagram to write or a request to flush the capture file
+type recordRequest struct {
+	record  *captureRecord
+	flushed chan error
+}
+
+// Recorder writes received datagrams to a gzip compressed capture file,
+// every line of the uncompressed content is one JSON encoded datagram.
+// The datagrams are compressed and written by its own goroutine.
+type Recorder struct {
+	file    *os.File
+	gz      *gzip.Writer
+	encoder *json.Encoder
+	queue   chan recordRequest
+	done    chan interface{}
+	closed  bool
+	sync.RWMutex
+}
+
+// NewRecorder opens the capture file, an existing file is continued
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	r := &Recorder{
+		file:    file,
+		gz:      gz,
+		encoder: json.NewEncoder(gz),
+		queue:   make(chan recordRequest, recordBufferSize),
+		done:    make(chan interface{}),
+	}
+	go r.writer()
+	return r, nil
+}
+
+// writer compresses the queued datagrams into the capture file
+func (r *Recorder) writer() {
+	defer close(r.done)
+	for request := range r.queue {
+		if request.flushed != nil {
+			request.flushed <- r.gz.Flush()
+			continue
+		}
+		if err := r.encoder.Encode(request.record); err != nil {
+			log.Println("unable to write capture file:", err)
+		}
+	}
+}
+
+// Record queues the response for the capture file, it is dropped if the queue is full
+func (r *Recorder) Record(res *Response) error {
+	record := &captureRecord{
+		Timestamp: res.Timestamp,
+		Interface: res.Interface,
+		Raw:       res.Raw,
+	}
+	if res.Address != nil {
+		record.Address = res.Address.String()
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+	if r.closed {
+		return errors.New("capture file is closed")
+	}
+	select {
+	case r.queue <- recordRequest{record: record}:
+		return nil
+	default:
+		return errors.New("capture queue is full, datagram dropped")
+	}
+}
+
+// Flush writes the queued datagrams to the capture file
+func (r *Recorder) Flush() error {
+	flushed := make(chan error, 1)
+	r.RLock()
+	if r.closed {
+		r.RUnlock()
+		return errors.New("capture file is closed")
+	}
+	r.queue <- recordRequest{flushed: flushed}
+	r.RUnlock()
+	return <-flushed
+}
+
+// Close writes the remaining datagrams and closes the capture file
+func (r *Recorder) Close() error {
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.queue)
+	r.Unlock()
+	<-r.done
+
+	if err := r.gz.Close(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// ReadCapture calls the given function for every datagram of the capture file in recorded order
+func ReadCapture(path string, f func(*Response) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// gzip reads directly from a io.ByteReader without a buffer of its own,
+	// so the remaining compressed data is known on an unexpected end
+	buffered := bufio.NewReader(file)
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	decoder := json.NewDecoder(gz)
+	for {
+		record := &captureRecord{}
+		if err = decoder.Decode(record); err == io.EOF {
+			return nil
+		} else if err == io.ErrUnexpectedEOF {
+			if _, peekErr := buffered.Peek(1); peekErr == io.EOF {
+				// the last datagrams were not flushed (e.g. yanic was killed)
+				return nil
+			}
+			return fmt.Errorf("truncated capture before the end of the file: %s", err)
+		} else if err != nil {
+			return err
+		}
+
+		res := &Response{
+			Interface: record.Interface,
+			Timestamp: record.Timestamp,
+			Raw:       record.Raw,
+		}
+		if record.Address != "" {
+			if res.Address, err = net.ResolveUDPAddr("udp", record.Address); err != nil {
+				return err
+			}
+		}
+		if err = f(res); err != nil {
+			return err
+		}
+	}
+}
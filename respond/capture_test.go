@@ -0,0 +1,155 @@
+package respond
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestCapture(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-capture")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture.gz")
+
+	now := time.Now()
+	responses := []*Response{
+		{
+			Address:   &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001, Zone: "lo"},
+			Interface: "lo",
+			Timestamp: now,
+			Raw:       []byte{1, 2, 3},
+		},
+		{
+			Address:   &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1001},
+			Timestamp: now.Add(time.Second),
+			Raw:       []byte{4},
+		},
+	}
+
+	recorder, err := NewRecorder(path)
+	assert.NoError(err)
+	assert.NoError(recorder.Record(responses[0]))
+	assert.NoError(recorder.Flush())
+	assert.NoError(recorder.Close())
+
+	// continue the capture
+	recorder, err = NewRecorder(path)
+	assert.NoError(err)
+	assert.NoError(recorder.Record(responses[1]))
+	assert.NoError(recorder.Close())
+
+	var read []*Response
+	err = ReadCapture(path, func(res *Response) error {
+		read = append(read, res)
+		return nil
+	})
+	assert.NoError(err)
+	assert.Len(read, 2)
+	for i, res := range read {
+		assert.Equal(responses[i].Address.String(), res.Address.String())
+		assert.Equal(responses[i].Interface, res.Interface)
+		assert.True(responses[i].Timestamp.Equal(res.Timestamp))
+		assert.Equal(responses[i].Raw, res.Raw)
+	}
+
+	_, err = NewRecorder(filepath.Join(dir, "missing", "capture.gz"))
+	assert.Error(err)
+	assert.Error(ReadCapture(filepath.Join(dir, "missing.gz"), nil))
+}
+
+func TestCaptureTruncated(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-capture")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture.gz")
+
+	record := func(raw byte) *Response {
+		return &Response{
+			Address:   &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1001},
+			Timestamp: time.Now(),
+			Raw:       []byte{raw},
+		}
+	}
+	count := func() (int, error) {
+		read := 0
+		err := ReadCapture(path, func(res *Response) error {
+			read++
+			return nil
+		})
+		return read, err
+	}
+
+	// complete member followed by a member without its end (e.g. yanic was killed)
+	recorder, err := NewRecorder(path)
+	assert.NoError(err)
+	assert.NoError(recorder.Record(record(1)))
+	assert.NoError(recorder.Close())
+	complete, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+
+	recorder, err = NewRecorder(path)
+	assert.NoError(err)
+	assert.NoError(recorder.Record(record(2)))
+	assert.NoError(recorder.Record(record(3)))
+	assert.NoError(recorder.Close())
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	truncated := content[:len(content)-10]
+
+	assert.NoError(ioutil.WriteFile(path, truncated, 0644))
+	read, err := count()
+	assert.NoError(err)
+	assert.True(read >= 1)
+
+	// the truncated member is followed by another one
+	assert.NoError(ioutil.WriteFile(path, append(append([]byte{}, truncated...), complete...), 0644))
+	_, err = count()
+	assert.Error(err)
+}
+
+func TestRecorderClosed(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-capture")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	recorder, err := NewRecorder(filepath.Join(dir, "capture.gz"))
+	assert.NoError(err)
+	assert.NoError(recorder.Close())
+	assert.NoError(recorder.Close())
+	assert.Error(recorder.Record(&Response{}))
+	assert.Error(recorder.Flush())
+}
+
+func TestCollectorReplay(t *testing.T) {
+	assert := assert.New(t)
+
+	compressed, err := ioutil.ReadFile("testdata/nodeinfo.flated")
+	assert.NoError(err)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	collector := NewCollector(nil, nodes, &Config{})
+	collector.Replay(&Response{
+		Address:   &net.UDPAddr{IP: net.ParseIP("fe80::1")},
+		Interface: "lo",
+		Timestamp: time.Now(),
+		Raw:       compressed,
+	})
+	collector.Close()
+
+	assert.Contains(nodes.List, "f81a67a5e9c1")
+	assert.EqualValues(1, collector.Stats().Interfaces["lo"].Received)
+}
@@ -3,10 +3,13 @@ package respond
 import (
 	"bytes"
 	"compress/flate"
+	"compress/gzip"
 	"encoding/json"
-	"fmt"
+	"io"
 	"log"
 	"net"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/FreifunkBremen/yanic/data"
@@ -17,112 +20,121 @@ import (
 
 // Collector for a specificle respond messages
 type Collector struct {
-	connections []multicastConn // UDP sockets
+	interfaces     []*collectorInterface // configured interfaces with their UDP sockets
+	interfaceLock  sync.RWMutex
+	interfacesDone bool // the UDP sockets are closed by Close
 
 	queue        chan *Response // received responses
+	dropOldest   bool           // drop the oldest response of a full queue instead of the newest
+	dbQueue      chan *dbJob    // responses to store in the database
+	stats        *collectorStats
 	db           database.Connection
 	nodes        *runtime.Nodes
 	sitesDomains map[string][]string
 	interval     time.Duration // Interval for multicast packets
+	intervals    map[string]time.Duration
+	schedule     *sectionSchedule // sections to request in a round, nil until started
+	dedup        *roundDedup      // one stored response per node and round
+	unicasts     *unicastScheduler
+	limiter      *rateLimiter // unicast requests per interface
+	addressGuard bool         // reject responses of online nodes from unusual addresses
 	stop         chan interface{}
+	recorder     *Recorder // optional capture of all received datagrams
+
+	receiverWG sync.WaitGroup
+	parserWG   sync.WaitGroup
+	dbWG       sync.WaitGroup
+
+	targets     []*target
+	targetAddrs map[string]string // mapping from resolved address to target key
+	targetLock  sync.RWMutex
+}
+
+// dbJob is a node to store in the database
+type dbJob struct {
+	node  *runtime.Node
+	links []runtime.Link
 }
 
 type multicastConn struct {
 	Conn             *net.UDPConn
+	InterfaceName    string
 	SendRequest      bool
 	MulticastAddress net.IP
+	Sections         []string // requested sections
 }
 
 // NewCollector creates a Collector struct
-func NewCollector(db database.Connection, nodes *runtime.Nodes, sitesDomains map[string][]string, ifaces []InterfaceConfig) *Collector {
+func NewCollector(db database.Connection, nodes *runtime.Nodes, config *Config) *Collector {
 
 	coll := &Collector{
 		db:           db,
 		nodes:        nodes,
-		sitesDomains: sitesDomains,
-		queue:        make(chan *Response, 400),
+		sitesDomains: config.SitesDomains(),
+		queue:        make(chan *Response, config.queueSize()),
+		dropOldest:   config.QueueDropPolicy == QueueDropOldest,
+		stats:        newCollectorStats(),
 		stop:         make(chan interface{}),
+		targetAddrs:  make(map[string]string),
+		intervals:    config.sectionIntervals(),
+		dedup:        newRoundDedup(config.dedupWindow()),
+		unicasts:     newUnicastScheduler(time.Now().UnixNano()),
+		limiter:      newRateLimiter(config.UnicastRate),
+		addressGuard: config.AddressGuard,
 	}
 
-	for _, iface := range ifaces {
-		coll.listenUDP(iface)
+	if policy := config.QueueDropPolicy; policy != "" && policy != QueueDropNewest && policy != QueueDropOldest {
+		log.Printf("invalid queue_drop_policy '%s', using %s", policy, QueueDropNewest)
 	}
 
-	go coll.parser()
-
-	if coll.db != nil {
-		go coll.globalStatsWorker()
+	now := time.Now()
+	for _, iface := range config.Interfaces {
+		if err := iface.validate(); err != nil {
+			log.Println("invalid interface:", err)
+			continue
+		}
+		coll.interfaces = append(coll.interfaces, coll.newInterface(iface, now))
 	}
+	go coll.interfaceWatcher()
 
-	return coll
-}
-
-func (coll *Collector) listenUDP(iface InterfaceConfig) {
-
-	var addr net.IP
-
-	var err error
-	if iface.IPAddress != "" {
-		addr = net.ParseIP(iface.IPAddress)
-	} else {
-		addr, err = getUnicastAddr(iface.InterfaceName, iface.MulticastAddress == "")
+	if config.RecordPath != "" {
+		recorder, err := NewRecorder(config.RecordPath)
 		if err != nil {
-			log.Panic(err)
+			log.Println("unable to record datagrams:", err)
+		} else {
+			coll.recorder = recorder
 		}
 	}
 
-	multicastAddress := multicastAddressDefault
-	if iface.MulticastAddress != "" {
-		multicastAddress = iface.MulticastAddress
+	var targetKeys []string
+	for _, targetConfig := range config.Targets {
+		targets, err := newTargets(targetConfig)
+		if err != nil {
+			log.Println("invalid target:", err)
+			continue
+		}
+		for _, t := range targets {
+			coll.targets = append(coll.targets, t)
+			targetKeys = append(targetKeys, t.key)
+		}
 	}
-
-	// Open socket
-	conn, err := net.ListenUDP("udp", &net.UDPAddr{
-		IP:   addr,
-		Port: iface.Port,
-		Zone: iface.InterfaceName,
-	})
-	if err != nil {
-		log.Panic(err)
+	if len(coll.targets) > 0 {
+		nodes.SetTargets(targetKeys)
 	}
-	conn.SetReadBuffer(maxDataGramSize)
-
-	coll.connections = append(coll.connections, multicastConn{
-		Conn:             conn,
-		SendRequest:      !iface.SendNoRequest,
-		MulticastAddress: net.ParseIP(multicastAddress),
-	})
 
-	// Start receiver
-	go coll.receiver(conn)
-}
-
-// Returns a unicast address of given interface (linklocal or global unicast address)
-func getUnicastAddr(ifname string, linklocal bool) (net.IP, error) {
-	iface, err := net.InterfaceByName(ifname)
-	if err != nil {
-		return nil, err
+	for i := 0; i < config.parserWorkers(); i++ {
+		coll.parserWG.Add(1)
+		go coll.parser()
 	}
 
-	addresses, err := iface.Addrs()
-	if err != nil {
-		return nil, err
+	if coll.db != nil {
+		coll.dbQueue = make(chan *dbJob, config.databaseQueueSize())
+		coll.dbWG.Add(1)
+		go coll.databaseWriter()
+		go coll.globalStatsWorker()
 	}
-	var ip net.IP
 
-	for _, addr := range addresses {
-		ipnet, ok := addr.(*net.IPNet)
-		if !ok {
-			continue
-		}
-		if (!linklocal && ipnet.IP.IsGlobalUnicast()) || (linklocal && ipnet.IP.IsLinkLocalUnicast()) {
-			ip = ipnet.IP
-		}
-	}
-	if ip != nil {
-		return ip, nil
-	}
-	return nil, fmt.Errorf("unable to find a unicast address for %s", ifname)
+	return coll
 }
 
 // Start Collector
@@ -134,6 +146,7 @@ func (coll *Collector) Start(interval time.Duration) {
 		panic("invalid collector interval")
 	}
 	coll.interval = interval
+	coll.schedule = newSectionSchedule(interval, coll.intervals)
 
 	go func() {
 		coll.sendOnce() // immediately
@@ -144,87 +157,230 @@ func (coll *Collector) Start(interval time.Duration) {
 // Close Collector
 func (coll *Collector) Close() {
 	close(coll.stop)
-	for _, conn := range coll.connections {
-		conn.Conn.Close()
+	coll.closeInterfaces()
+	coll.receiverWG.Wait()
+	if coll.recorder != nil {
+		if err := coll.recorder.Close(); err != nil {
+			log.Println("unable to close capture file:", err)
+		}
 	}
+
+	// process the received responses and stored them in the database
 	close(coll.queue)
+	coll.parserWG.Wait()
+	coll.flushPending()
+	if coll.dbQueue != nil {
+		close(coll.dbQueue)
+		coll.dbWG.Wait()
+	}
 }
 
 func (coll *Collector) sendOnce() {
 	now := jsontime.Now()
+	coll.stats.newRound(now.GetTime())
+	coll.schedule.newRound(now.GetTime())
+	coll.dedup.newRound(now.GetTime())
 	coll.sendMulticast()
-
-	// Wait for the multicast responses to be processed and send unicasts
-	time.Sleep(coll.interval / 2)
-	coll.sendUnicasts(now)
+	coll.sendTargets()
+	coll.sendUnicasts(now.GetTime())
 }
 
 func (coll *Collector) sendMulticast() {
 	log.Println("sending multicasts")
-	for _, conn := range coll.connections {
+	for _, conn := range coll.getConnections() {
 		if conn.SendRequest {
-			coll.sendPacket(conn.Conn, conn.MulticastAddress)
+			coll.sendPacket(conn, conn.MulticastAddress)
 		}
 	}
 }
 
-// Send unicast packets to nodes that did not answer the multicast
-func (coll *Collector) sendUnicasts(seenBefore jsontime.Time) {
-	seenAfter := seenBefore.Add(-time.Minute * 10)
-
-	// Select online nodes that has not been seen recently
-	nodes := coll.nodes.Select(func(n *runtime.Node) bool {
-		return n.Lastseen.After(seenAfter) && n.Lastseen.Before(seenBefore) && n.Address != nil
-	})
+// Send unicast packets to nodes that did not answer the multicast,
+// the packets are spread over the interval and limited per interface
+func (coll *Collector) sendUnicasts(round time.Time) {
+	interval := coll.schedule.tick()
+	coll.nodes.RLock()
+	requests := coll.unicasts.plan(coll.nodes.List, round, interval, coll.nodes.OfflineAfter())
+	coll.nodes.RUnlock()
+
+	end := round.Add(interval)
+	count, skipped := 0, 0
+	for _, request := range requests {
+		if !coll.sleepUntil(round.Add(request.offset)) {
+			return
+		}
+		if coll.seenSince(request.nodeID, round) {
+			continue
+		}
 
-	// Send unicast packets
-	count := 0
-	for _, node := range nodes {
 		send := 0
-		for _, conn := range coll.connections {
-			if node.Address.Zone != "" && conn.Conn.LocalAddr().(*net.UDPAddr).Zone != node.Address.Zone && conn.SendRequest {
+		for _, conn := range coll.getConnections() {
+			local := conn.Conn.LocalAddr().(*net.UDPAddr)
+			if request.addr.Zone != "" && local.Zone != request.addr.Zone && conn.SendRequest {
+				continue
+			}
+			if !local.IP.IsUnspecified() && (local.IP.To4() == nil) != (request.addr.IP.To4() == nil) {
+				continue
+			}
+			at := coll.limiter.reserve(conn.InterfaceName, time.Now())
+			if at.After(end) {
 				continue
 			}
-			coll.sendPacket(conn.Conn, node.Address.IP)
+			if !coll.sleepUntil(at) {
+				return
+			}
+			coll.sendPacket(conn, request.addr.IP)
 			send++
 		}
 		if send == 0 {
-			log.Printf("unable to find connection for %s", node.Address.Zone)
-		} else {
-			time.Sleep(10 * time.Millisecond)
-			count += send
+			coll.unicasts.skipped(request.nodeID)
+			skipped++
 		}
+		count += send
+	}
+	log.Printf("sending %d unicast pkg for %d nodes", count, len(requests)-skipped)
+	if skipped > 0 {
+		log.Printf("unable to send unicast pkg for %d nodes: no connection or rate limit reached", skipped)
 	}
-	log.Printf("sending %d unicast pkg for %d nodes", count, len(nodes))
+}
+
+// sleepUntil waits until the given time, it returns false if the collector is closed
+func (coll *Collector) sleepUntil(t time.Time) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		select {
+		case <-coll.stop:
+			return false
+		default:
+			return true
+		}
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-coll.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// seenSince returns true if the node responded since the given time
+func (coll *Collector) seenSince(nodeID string, since time.Time) bool {
+	coll.nodes.RLock()
+	defer coll.nodes.RUnlock()
+	node := coll.nodes.List[nodeID]
+	return node != nil && !node.Lastseen.GetTime().Before(since)
+}
+
+// Send unicast packets to the statically configured targets
+func (coll *Collector) sendTargets() {
+	if len(coll.targets) == 0 {
+		return
+	}
+
+	count := 0
+	for _, t := range coll.targets {
+		coll.nodes.TargetRequested(t.key)
+
+		ips, err := t.resolve()
+		if err != nil {
+			log.Printf("unable to resolve target %s: %s", t.key, err)
+			continue
+		}
+		for _, ip := range ips {
+			conn := coll.targetConnection(t, ip)
+			if conn == nil {
+				log.Printf("unable to find connection for target %s", ip)
+				continue
+			}
+
+			coll.targetLock.Lock()
+			coll.targetAddrs[ip.String()] = t.key
+			coll.targetLock.Unlock()
+
+			addr := &net.UDPAddr{IP: ip, Port: t.port}
+			if ip.IsLinkLocalUnicast() {
+				addr.Zone = conn.InterfaceName
+			}
+			coll.writeRequest(conn, addr)
+			count++
+		}
+	}
+	log.Printf("sending %d unicast pkg for %d targets", count, len(coll.targets))
+}
+
+// targetConnection returns a UDP socket which is able to reach the given address of the target
+func (coll *Collector) targetConnection(t *target, ip net.IP) *multicastConn {
+	connections := coll.getConnections()
+	for i, conn := range connections {
+		if !conn.SendRequest || (t.ifname != "" && conn.InterfaceName != t.ifname) {
+			continue
+		}
+		local := conn.Conn.LocalAddr().(*net.UDPAddr).IP
+		if local.IsUnspecified() {
+			return &connections[i]
+		}
+		if (local.To4() == nil) != (ip.To4() == nil) {
+			continue
+		}
+		if local.IsLinkLocalUnicast() && !ip.IsLinkLocalUnicast() {
+			continue
+		}
+		return &connections[i]
+	}
+	return nil
 }
 
 // SendPacket sends a UDP request to the given unicast or multicast address on the first UDP socket
 func (coll *Collector) SendPacket(destination net.IP) {
-	coll.sendPacket(coll.connections[0].Conn, destination)
+	connections := coll.getConnections()
+	if len(connections) == 0 {
+		log.Println("unable to send packet: no interface is bound")
+		return
+	}
+	coll.sendPacket(connections[0], destination)
 }
 
-// sendPacket sends a UDP request to the given unicast or multicast address on the given UDP socket
-func (coll *Collector) sendPacket(conn *net.UDPConn, destination net.IP) {
-	addr := net.UDPAddr{
+// sendPacket sends a UDP request with the configured sections to the given unicast or multicast address on the given UDP socket
+func (coll *Collector) sendPacket(conn multicastConn, destination net.IP) {
+	coll.writeRequest(&conn, &net.UDPAddr{
 		IP:   destination,
 		Port: port,
-		Zone: conn.LocalAddr().(*net.UDPAddr).Zone,
-	}
+		Zone: conn.Conn.LocalAddr().(*net.UDPAddr).Zone,
+	})
+}
 
-	if _, err := conn.WriteToUDP([]byte("GET nodeinfo statistics neighbours"), &addr); err != nil {
+// writeRequest writes a request of the sections of the UDP socket which are due to the given address
+func (coll *Collector) writeRequest(conn *multicastConn, addr *net.UDPAddr) {
+	sections := conn.Sections
+	if coll.schedule != nil {
+		if sections = coll.schedule.due(sections); len(sections) == 0 {
+			return
+		}
+	}
+	if _, err := conn.Conn.WriteToUDP(requestPacket(sections), addr); err != nil {
 		log.Println("WriteToUDP failed:", err)
+		return
 	}
+	// the multicast address of an IPv4 interface might be a broadcast address
+	group := addr.IP.IsMulticast() || addr.IP.Equal(conn.MulticastAddress) || addr.IP.Equal(net.IPv4bcast)
+	coll.stats.requestSent(conn.InterfaceName, addr, group)
 }
 
 // send packets continuously
 func (coll *Collector) sender() {
-	ticker := time.NewTicker(coll.interval)
+	ticker := time.NewTicker(coll.schedule.tick())
 	for {
 		select {
 		case <-coll.stop:
 			ticker.Stop()
 			return
 		case <-ticker.C:
+			if coll.recorder != nil {
+				if err := coll.recorder.Flush(); err != nil {
+					log.Println("unable to write capture file:", err)
+				}
+			}
 			// send the multicast packet to request per-node statistics
 			coll.sendOnce()
 		}
@@ -232,75 +388,129 @@ func (coll *Collector) sender() {
 }
 
 func (coll *Collector) parser() {
+	defer coll.parserWG.Done()
 	for obj := range coll.queue {
 		if data, err := obj.parse(); err != nil {
+			atomic.AddUint64(&coll.stats.decodeErrors, 1)
 			log.Println("unable to decode response from", obj.Address.String(), err)
-		} else {
-			coll.saveResponse(obj.Address, data)
+		} else if nodeID, ok := coll.validateResponse(obj.Address, data); ok && coll.checkSource(nodeID, obj) {
+			coll.dedupResponse(&dedupResponse{
+				nodeID:   nodeID,
+				priority: coll.interfacePriority(obj.Interface),
+				response: obj,
+				data:     data,
+			})
 		}
 	}
 }
 
 func (res *Response) parse() (*data.ResponseData, error) {
-	// Deflate
-	deflater := flate.NewReader(bytes.NewReader(res.Raw))
-	defer deflater.Close()
+	format := payloadFormat(res.Raw)
+	rdata, err := decodePayload(res.Raw, format)
+	if err != nil && format == formatJSON {
+		// a raw deflate stream could start with '{' as well
+		return decodePayload(res.Raw, formatDeflate)
+	}
+	return rdata, err
+}
+
+// decodePayload decompresses and unmarshals a response in the given format
+func decodePayload(raw []byte, format int) (*data.ResponseData, error) {
+	var reader io.Reader
+	switch format {
+	case formatJSON:
+		reader = bytes.NewReader(raw)
+	case formatGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	default:
+		deflater := flate.NewReader(bytes.NewReader(raw))
+		defer deflater.Close()
+		reader = deflater
+	}
 
 	// Unmarshal
 	rdata := &data.ResponseData{}
-	err := json.NewDecoder(deflater).Decode(rdata)
+	err := json.NewDecoder(reader).Decode(rdata)
 
 	return rdata, err
 }
 
-func (coll *Collector) saveResponse(addr *net.UDPAddr, res *data.ResponseData) {
-	// Search for NodeID
-	var nodeID string
-	if val := res.NodeInfo; val != nil {
-		nodeID = val.NodeID
-	} else if val := res.Neighbours; val != nil {
-		nodeID = val.NodeID
-	} else if val := res.Statistics; val != nil {
-		nodeID = val.NodeID
-	}
+// validateResponse returns the NodeID of the response and drops sections of other nodes,
+// it returns false for invalid responses
+func (coll *Collector) validateResponse(addr *net.UDPAddr, res *data.ResponseData) (string, bool) {
+	nodeID := res.NodeID()
 
 	// Check length of nodeID
 	if len(nodeID) != 12 {
+		atomic.AddUint64(&coll.stats.invalidNodeIDs, 1)
 		log.Printf("invalid NodeID '%s' from %s", nodeID, addr.String())
-		return
+		return "", false
 	}
 
 	// Set fields to nil if nodeID is inconsistent
-	if res.Statistics != nil && res.Statistics.NodeID != nodeID {
-		res.Statistics = nil
-	}
-	if res.Neighbours != nil && res.Neighbours.NodeID != nodeID {
-		res.Neighbours = nil
-	}
-	if res.NodeInfo != nil && res.NodeInfo.NodeID != nodeID {
-		res.NodeInfo = nil
+	if dropped := res.DropMismatches(nodeID); dropped > 0 {
+		atomic.AddUint64(&coll.stats.nodeIDMismatches, uint64(dropped))
 	}
+	return nodeID, true
+}
 
+// saveResponse stores a valid response to the nodes and database
+func (coll *Collector) saveResponse(nodeID string, addr *net.UDPAddr, res *data.ResponseData) {
 	// Process the data and update IP address
 	node := coll.nodes.Update(nodeID, res)
+	coll.nodes.Lock()
 	node.Address = addr
+	node.Source = Source
+	coll.nodes.Unlock()
+
+	coll.targetResponded(nodeID, addr)
 
 	// Store statistics in database
-	if db := coll.db; db != nil {
-		db.InsertNode(node)
-
-		// Store link data
-		if neighbours := node.Neighbours; neighbours != nil {
-			coll.nodes.RLock()
-			for _, link := range coll.nodes.NodeLinks(node) {
-				db.InsertLink(&link, node.Lastseen.GetTime())
-			}
-			coll.nodes.RUnlock()
+	if coll.dbQueue != nil {
+		coll.nodes.RLock()
+		job := &dbJob{node: &runtime.Node{}}
+		*job.node = *node
+		if node.Neighbours != nil {
+			job.links = coll.nodes.NodeLinks(node)
+		}
+		coll.nodes.RUnlock()
+
+		select {
+		case coll.dbQueue <- job:
+		default:
+			atomic.AddUint64(&coll.stats.databaseDropped, 1)
 		}
 	}
 }
 
-func (coll *Collector) receiver(conn *net.UDPConn) {
+// targetResponded marks the target of the address as reachable
+func (coll *Collector) targetResponded(nodeID string, addr *net.UDPAddr) {
+	coll.targetLock.RLock()
+	targetKey, isTarget := coll.targetAddrs[addr.IP.String()]
+	coll.targetLock.RUnlock()
+	if isTarget {
+		coll.nodes.TargetResponded(targetKey, nodeID)
+	}
+}
+
+// databaseWriter stores the nodes and links of the parsed responses
+func (coll *Collector) databaseWriter() {
+	defer coll.dbWG.Done()
+	for job := range coll.dbQueue {
+		coll.db.InsertNode(job.node)
+		for i := range job.links {
+			coll.db.InsertLink(&job.links[i], job.node.Lastseen.GetTime())
+		}
+	}
+}
+
+func (coll *Collector) receiver(conn *net.UDPConn, ifname string) {
+	defer coll.receiverWG.Done()
 	buf := make([]byte, maxDataGramSize)
 	for {
 		n, src, err := conn.ReadFromUDP(buf)
@@ -313,9 +523,48 @@ func (coll *Collector) receiver(conn *net.UDPConn) {
 		raw := make([]byte, n)
 		copy(raw, buf)
 
-		coll.queue <- &Response{
-			Address: src,
-			Raw:     raw,
+		res := &Response{
+			Address:   src,
+			Interface: ifname,
+			Timestamp: time.Now(),
+			Raw:       raw,
+		}
+		if coll.recorder != nil {
+			if err := coll.recorder.Record(res); err != nil {
+				log.Println("unable to record datagram:", err)
+			}
+		}
+
+		coll.stats.received(ifname)
+		coll.enqueue(res)
+	}
+}
+
+// Replay passes a recorded response to the parsers like a received one,
+// if the queue is full it waits instead of dropping the response
+func (coll *Collector) Replay(res *Response) {
+	coll.stats.received(res.Interface)
+	coll.queue <- res
+}
+
+// enqueue adds the response to the queue of the parsers without blocking,
+// if the queue is full a response is dropped by the configured policy
+func (coll *Collector) enqueue(res *Response) {
+	for {
+		select {
+		case coll.queue <- res:
+			return
+		default:
+		}
+
+		if !coll.dropOldest {
+			atomic.AddUint64(&coll.stats.queueDropped, 1)
+			return
+		}
+		select {
+		case <-coll.queue:
+			atomic.AddUint64(&coll.stats.queueDropped, 1)
+		default:
 		}
 	}
 }
@@ -329,6 +578,7 @@ func (coll *Collector) globalStatsWorker() {
 			return
 		case <-ticker.C:
 			coll.saveGlobalStats()
+			coll.db.InsertCollectorStats(coll.Stats(), time.Now())
 		}
 	}
 }
@@ -343,3 +593,13 @@ func (coll *Collector) saveGlobalStats() {
 		}
 	}
 }
+
+// Stats returns the current self-metrics of the collector
+func (coll *Collector) Stats() *runtime.CollectorStats {
+	stats := coll.stats.get()
+	stats.QueueLength = len(coll.queue)
+	stats.QueueCapacity = cap(coll.queue)
+	stats.DatabaseQueueLength = len(coll.dbQueue)
+	coll.interfaceStats(stats)
+	return stats
+}
@@ -1,10 +1,16 @@
 package respond
 
 import (
+	"bytes"
+	"compress/gzip"
 	"io/ioutil"
+	"net"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/database"
 	"github.com/FreifunkBremen/yanic/runtime"
 	"github.com/stretchr/testify/assert"
 )
@@ -17,7 +23,9 @@ const (
 func TestCollector(t *testing.T) {
 	nodes := runtime.NewNodes(&runtime.NodesConfig{})
 
-	collector := NewCollector(nil, nodes, map[string][]string{SITE_TEST: {DOMAIN_TEST}}, []InterfaceConfig{})
+	collector := NewCollector(nil, nodes, &Config{
+		Sites: map[string]SiteConfig{SITE_TEST: {Domains: []string{DOMAIN_TEST}}},
+	})
 	collector.Start(time.Millisecond)
 	time.Sleep(time.Millisecond * 10)
 	collector.Close()
@@ -41,3 +49,161 @@ func TestParse(t *testing.T) {
 
 	assert.Equal("f81a67a5e9c1", data.NodeInfo.NodeID)
 }
+
+func TestParseFormats(t *testing.T) {
+	assert := assert.New(t)
+
+	plain := []byte(`{"nodeinfo":{"node_id":"f81a67a5e9c1"}}`)
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write(plain)
+	gz.Close()
+	deflated, err := ioutil.ReadFile("testdata/nodeinfo.flated")
+	assert.NoError(err)
+
+	assert.Equal(formatJSON, payloadFormat(append([]byte(" \n"), plain...)))
+	assert.Equal(formatGzip, payloadFormat(gzipped.Bytes()))
+	assert.Equal(formatDeflate, payloadFormat(deflated))
+
+	for _, raw := range [][]byte{plain, gzipped.Bytes(), deflated} {
+		data, err := (&Response{Raw: raw}).parse()
+		assert.NoError(err)
+		assert.Equal("f81a67a5e9c1", data.NodeInfo.NodeID)
+	}
+
+	_, err = (&Response{Raw: []byte("{no json")}).parse()
+	assert.Error(err)
+}
+
+func TestRequestPacket(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("GET nodeinfo statistics neighbours", string(requestPacket(nil)))
+	assert.Equal("GET nodeinfo wireless", string(requestPacket([]string{"nodeinfo", "wireless"})))
+}
+
+func TestCollectorTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	compressed, err := ioutil.ReadFile("testdata/nodeinfo.flated")
+	assert.NoError(err)
+
+	// fake respondd of a node
+	respondd, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(err)
+	defer respondd.Close()
+	requests := make(chan string, 1)
+	go func() {
+		buf := make([]byte, maxDataGramSize)
+		n, src, err := respondd.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		requests <- string(buf[:n])
+		respondd.WriteToUDP(compressed, src)
+	}()
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	collector := NewCollector(nil, nodes, &Config{
+		Interfaces: []InterfaceConfig{{InterfaceName: "lo", IPAddress: "127.0.0.1", Sections: []string{"nodeinfo"}}},
+		Targets: []TargetConfig{
+			{Address: "127.0.0.1", Port: respondd.LocalAddr().(*net.UDPAddr).Port},
+			{Address: "::1"},
+		},
+	})
+	defer collector.Close()
+
+	collector.sendTargets()
+	assert.Equal("GET nodeinfo", <-requests)
+
+	time.Sleep(time.Millisecond * 100)
+	assert.Len(nodes.Select(func(n *runtime.Node) bool {
+		return n.Nodeinfo != nil && n.Nodeinfo.NodeID == "f81a67a5e9c1"
+	}), 1)
+
+	targets := nodes.GetTargets()
+	assert.Len(targets, 2)
+	assert.True(targets["127.0.0.1"].Reachable)
+	assert.Equal("f81a67a5e9c1", targets["127.0.0.1"].NodeID)
+	assert.False(targets["::1"].Reachable)
+
+	stats := collector.Stats()
+	assert.EqualValues(1, stats.UnicastsSent)
+	assert.EqualValues(1, stats.Responses)
+	assert.EqualValues(1, stats.Interfaces["lo"].Received)
+	assert.Equal(400, stats.QueueCapacity)
+}
+
+func TestEnqueue(t *testing.T) {
+	assert := assert.New(t)
+
+	first := &Response{Interface: "first"}
+	second := &Response{Interface: "second"}
+
+	coll := &Collector{
+		queue: make(chan *Response, 1),
+		stats: newCollectorStats(),
+	}
+	coll.enqueue(first)
+	coll.enqueue(second)
+	assert.Equal(first, <-coll.queue)
+	assert.EqualValues(1, coll.Stats().QueueDropped)
+
+	coll.dropOldest = true
+	coll.enqueue(first)
+	coll.enqueue(second)
+	assert.Equal(second, <-coll.queue)
+	assert.EqualValues(2, coll.Stats().QueueDropped)
+}
+
+type testConnection struct {
+	database.Connection
+	sync.Mutex
+	nodes int
+	links int
+}
+
+func (c *testConnection) InsertNode(node *runtime.Node) {
+	c.Lock()
+	c.nodes++
+	c.Unlock()
+}
+
+func (c *testConnection) InsertLink(link *runtime.Link, t time.Time) {
+	c.Lock()
+	c.links++
+	c.Unlock()
+}
+
+func TestDatabaseWriter(t *testing.T) {
+	assert := assert.New(t)
+
+	db := &testConnection{}
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	collector := NewCollector(db, nodes, &Config{ParserWorkers: 2, QueueDropPolicy: "invalid"})
+
+	nodes.Update("f81a67a601eb", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{NodeID: "f81a67a601eb", Network: data.Network{Mac: "f8:1a:67:a6:01:eb"}},
+	})
+	res := &data.ResponseData{
+		Neighbours: &data.Neighbours{
+			NodeID: "f81a67a601ea",
+			Batadv: map[string]data.BatadvNeighbours{
+				"f8:1a:67:a6:01:ea": {Neighbours: map[string]data.BatmanLink{
+					"f8:1a:67:a6:01:eb": {Tq: 200},
+				}},
+			},
+		},
+	}
+	nodeID, ok := collector.validateResponse(&net.UDPAddr{}, res)
+	assert.True(ok)
+	collector.saveResponse(nodeID, &net.UDPAddr{}, res)
+	_, ok = collector.validateResponse(&net.UDPAddr{}, &data.ResponseData{})
+	assert.False(ok)
+
+	// wait for the database writer
+	collector.Close()
+	assert.Equal(1, db.nodes)
+	assert.Equal(1, db.links)
+	assert.EqualValues(1, collector.Stats().InvalidNodeIDs)
+}
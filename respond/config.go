@@ -1,13 +1,97 @@
 package respond
 
-import "github.com/FreifunkBremen/yanic/lib/duration"
+import (
+	goruntime "runtime"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+// Policies to drop a response if the queue of the parsers is full
+const (
+	QueueDropNewest = "drop-newest"
+	QueueDropOldest = "drop-oldest"
+)
+
+const (
+	queueSizeDefault         = 400
+	databaseQueueSizeDefault = 1000
+)
 
 type Config struct {
 	Enable          bool                  `toml:"enable"`
 	Synchronize     duration.Duration     `toml:"synchronize"`
 	Interfaces      []InterfaceConfig     `toml:"interfaces"`
+	Targets         []TargetConfig        `toml:"targets"`
 	Sites           map[string]SiteConfig `toml:"sites"`
 	CollectInterval duration.Duration     `toml:"collect_interval"`
+
+	SectionIntervals map[string]duration.Duration `toml:"section_intervals"` // intervals of single sections, default is collect_interval
+
+	ParserWorkers     int    `toml:"parser_workers"`
+	QueueSize         int    `toml:"queue_size"`
+	QueueDropPolicy   string `toml:"queue_drop_policy"`
+	DatabaseQueueSize int    `toml:"database_queue_size"`
+	UnicastRate       int    `toml:"unicast_rate"` // unicast requests per second and interface
+
+	RecordPath string `toml:"record_path"` // capture file of all received datagrams
+
+	DedupWindow duration.Duration `toml:"dedup_window"` // time to wait for a response of an interface with higher priority
+
+	AddressGuard bool `toml:"address_guard"` // reject responses of online nodes from another address than usual
+}
+
+func (c *Config) parserWorkers() int {
+	if c.ParserWorkers > 0 {
+		return c.ParserWorkers
+	}
+	return goruntime.NumCPU()
+}
+
+func (c *Config) queueSize() int {
+	if c.QueueSize > 0 {
+		return c.QueueSize
+	}
+	return queueSizeDefault
+}
+
+func (c *Config) databaseQueueSize() int {
+	if c.DatabaseQueueSize > 0 {
+		return c.DatabaseQueueSize
+	}
+	return databaseQueueSizeDefault
+}
+
+// dedupWindow defaults to a second if interface priorities are configured
+func (c *Config) dedupWindow() time.Duration {
+	if c.DedupWindow.Duration > 0 {
+		return c.DedupWindow.Duration
+	}
+	for _, iface := range c.Interfaces {
+		if iface.Priority != 0 {
+			return dedupWindowDefault
+		}
+	}
+	return 0
+}
+
+func (c *Config) sectionIntervals() map[string]time.Duration {
+	intervals := make(map[string]time.Duration, len(c.SectionIntervals))
+	for section, interval := range c.SectionIntervals {
+		intervals[section] = interval.Duration
+	}
+	return intervals
+}
+
+// LongestInterval returns the longest interval in which a section is requested
+func (c *Config) LongestInterval() time.Duration {
+	longest := c.CollectInterval.Duration
+	for _, interval := range c.SectionIntervals {
+		if interval.Duration > longest {
+			longest = interval.Duration
+		}
+	}
+	return longest
 }
 
 func (c *Config) SitesDomains() (result map[string][]string) {
@@ -23,9 +107,18 @@ type SiteConfig struct {
 }
 
 type InterfaceConfig struct {
-	InterfaceName    string `toml:"ifname"`
-	IPAddress        string `toml:"ip_address"`
-	SendNoRequest    bool   `toml:"send_no_request"`
-	MulticastAddress string `toml:"multicast_address"`
-	Port             int    `toml:"port"`
+	InterfaceName    string   `toml:"ifname"`
+	IPAddress        string   `toml:"ip_address"`
+	SendNoRequest    bool     `toml:"send_no_request"`
+	MulticastAddress string   `toml:"multicast_address"`
+	Port             int      `toml:"port"`
+	Sections         []string `toml:"sections"`
+	Priority         int      `toml:"priority"`        // responses of interfaces with higher priority are preferred
+	AllowedSources   []string `toml:"allowed_sources"` // prefixes of accepted source addresses, default all
+}
+
+type TargetConfig struct {
+	Address       string `toml:"address"`
+	InterfaceName string `toml:"ifname"`
+	Port          int    `toml:"port"`
 }
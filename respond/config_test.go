@@ -2,7 +2,9 @@ package respond
 
 import (
 	"testing"
+	"time"
 
+	"github.com/naoina/toml"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +24,17 @@ func TestSitesDomainsConfigTransform(t *testing.T) {
 	assert.Len(domains, 1)
 	assert.Equal("city", domains[0])
 }
+
+func TestSectionIntervalsConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	c := Config{}
+	err := toml.Unmarshal([]byte(`
+collect_interval = "1m"
+[section_intervals]
+nodeinfo = "15m"
+`), &c)
+	assert.NoError(err)
+	assert.Equal(map[string]time.Duration{"nodeinfo": 15 * time.Minute}, c.sectionIntervals())
+	assert.Equal(15*time.Minute, c.LongestInterval())
+}
@@ -0,0 +1,146 @@
+package respond
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+// default time to wait for the responses of other interfaces, if priorities are configured
+const dedupWindowDefault = time.Second
+
+// dedupResponse is a valid response waiting for the decision of the deduplication
+type dedupResponse struct {
+	nodeID   string
+	priority int // priority of the receiving interface
+	response *Response
+	data     *data.ResponseData
+
+	round time.Time
+	timer *time.Timer
+}
+
+// roundDedup accepts one response per node and round
+type roundDedup struct {
+	window time.Duration // time to wait for responses with a higher priority
+
+	sync.Mutex
+	round    time.Time
+	accepted map[string]bool           // nodes with an accepted response in this round
+	pending  map[string]*dedupResponse // responses waiting for the window, indexed by NodeID
+	timers   sync.WaitGroup
+}
+
+func newRoundDedup(window time.Duration) *roundDedup {
+	return &roundDedup{
+		window:   window,
+		accepted: make(map[string]bool),
+		pending:  make(map[string]*dedupResponse),
+	}
+}
+
+// newRound forgets the accepted responses of the last round
+func (d *roundDedup) newRound(start time.Time) {
+	d.Lock()
+	d.round = start
+	d.accepted = make(map[string]bool)
+	d.Unlock()
+}
+
+// dedupResponse saves the first response of a node in a round,
+// within the window a response of an interface with higher priority is preferred.
+// Other responses of the node only count as reachability.
+// Without rounds (the collector is not started) every response is saved.
+func (coll *Collector) dedupResponse(r *dedupResponse) {
+	d := coll.dedup
+	d.Lock()
+
+	if d.round.IsZero() {
+		d.Unlock()
+		coll.acceptResponse(r)
+		return
+	}
+	if d.accepted[r.nodeID] {
+		d.Unlock()
+		coll.duplicateResponse(r)
+		return
+	}
+	if d.window <= 0 {
+		d.accepted[r.nodeID] = true
+		d.Unlock()
+		coll.acceptResponse(r)
+		return
+	}
+
+	if pending := d.pending[r.nodeID]; pending != nil {
+		if r.priority > pending.priority {
+			// replace the waiting response
+			r.timer, pending.timer = pending.timer, nil
+			r.round = pending.round
+			d.pending[r.nodeID] = r
+			r = pending
+		}
+		d.Unlock()
+		coll.duplicateResponse(r)
+		return
+	}
+
+	r.round = d.round
+	d.pending[r.nodeID] = r
+	d.timers.Add(1)
+	nodeID := r.nodeID
+	r.timer = time.AfterFunc(d.window, func() {
+		defer d.timers.Done()
+		coll.acceptPending(nodeID)
+	})
+	d.Unlock()
+}
+
+// acceptPending saves the waiting response of the node
+func (coll *Collector) acceptPending(nodeID string) {
+	d := coll.dedup
+	d.Lock()
+	r := d.pending[nodeID]
+	delete(d.pending, nodeID)
+	if r != nil && r.round.Equal(d.round) {
+		d.accepted[nodeID] = true
+	}
+	d.Unlock()
+
+	if r != nil {
+		coll.acceptResponse(r)
+	}
+}
+
+// flushPending saves all waiting responses immediately
+func (coll *Collector) flushPending() {
+	d := coll.dedup
+	d.Lock()
+	var nodeIDs []string
+	for nodeID, r := range d.pending {
+		if r.timer.Stop() {
+			d.timers.Done()
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	d.Unlock()
+
+	for _, nodeID := range nodeIDs {
+		coll.acceptPending(nodeID)
+	}
+	d.timers.Wait()
+}
+
+func (coll *Collector) acceptResponse(r *dedupResponse) {
+	coll.saveResponse(r.nodeID, r.response.Address, r.data)
+	coll.stats.response(r.response)
+}
+
+// duplicateResponse marks the node as seen without storing the response again
+func (coll *Collector) duplicateResponse(r *dedupResponse) {
+	atomic.AddUint64(&coll.stats.duplicates, 1)
+	coll.nodes.Seen(r.nodeID)
+	coll.targetResponded(r.nodeID, r.response.Address)
+}
@@ -0,0 +1,110 @@
+package respond
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func newDedupCollector(window time.Duration) *Collector {
+	return &Collector{
+		nodes:       runtime.NewNodes(&runtime.NodesConfig{}),
+		stats:       newCollectorStats(),
+		dedup:       newRoundDedup(window),
+		targetAddrs: make(map[string]string),
+		interfaces: []*collectorInterface{
+			{config: InterfaceConfig{InterfaceName: "bat0", Priority: 10}},
+			{config: InterfaceConfig{InterfaceName: "br-client"}},
+		},
+	}
+}
+
+func dedupTestResponse(coll *Collector, ifname, hostname string) *dedupResponse {
+	return &dedupResponse{
+		nodeID:   "f81a67a601ea",
+		priority: coll.interfacePriority(ifname),
+		response: &Response{Interface: ifname, Address: &net.UDPAddr{IP: net.ParseIP("fe80::1")}, Timestamp: time.Now()},
+		data: &data.ResponseData{
+			NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: hostname},
+		},
+	}
+}
+
+func TestDedupWithoutWindow(t *testing.T) {
+	assert := assert.New(t)
+	coll := newDedupCollector(0)
+
+	// every response is stored before the collector is started
+	coll.dedupResponse(dedupTestResponse(coll, "br-client", "first"))
+	coll.dedupResponse(dedupTestResponse(coll, "br-client", "second"))
+	assert.EqualValues(2, coll.stats.get().Responses)
+
+	// only the first response of a round is stored
+	coll.dedup.newRound(time.Now())
+	coll.dedupResponse(dedupTestResponse(coll, "br-client", "third"))
+	coll.dedupResponse(dedupTestResponse(coll, "bat0", "fourth"))
+	stats := coll.stats.get()
+	assert.EqualValues(3, stats.Responses)
+	assert.EqualValues(1, stats.Duplicates)
+	assert.Equal("third", coll.nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+
+	// next round
+	coll.dedup.newRound(time.Now())
+	coll.dedupResponse(dedupTestResponse(coll, "br-client", "fifth"))
+	assert.Equal("fifth", coll.nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+}
+
+func TestDedupPriority(t *testing.T) {
+	assert := assert.New(t)
+	coll := newDedupCollector(time.Hour)
+	assert.Equal(10, coll.interfacePriority("bat0"))
+	assert.Equal(0, coll.interfacePriority("unknown"))
+
+	coll.dedup.newRound(time.Now())
+	coll.dedupResponse(dedupTestResponse(coll, "br-client", "client"))
+	coll.dedupResponse(dedupTestResponse(coll, "bat0", "bat"))
+	coll.dedupResponse(dedupTestResponse(coll, "br-client", "client"))
+	assert.Nil(coll.nodes.List["f81a67a601ea"], "waiting for the window")
+
+	// the response of the preferred interface is stored on close
+	coll.flushPending()
+	stats := coll.stats.get()
+	assert.EqualValues(1, stats.Responses)
+	assert.EqualValues(2, stats.Duplicates)
+	assert.Equal("bat", coll.nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+
+	// the node is accepted for this round
+	coll.dedupResponse(dedupTestResponse(coll, "bat0", "late"))
+	assert.EqualValues(3, coll.stats.get().Duplicates)
+	assert.Equal("bat", coll.nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+}
+
+func TestDedupWindow(t *testing.T) {
+	assert := assert.New(t)
+	coll := newDedupCollector(time.Millisecond)
+
+	coll.dedup.newRound(time.Now())
+	coll.dedupResponse(dedupTestResponse(coll, "br-client", "client"))
+	coll.dedup.timers.Wait()
+	assert.EqualValues(1, coll.stats.get().Responses)
+	assert.Equal("client", coll.nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+	assert.Empty(coll.dedup.pending)
+}
+
+func TestDedupWindowConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Config{Interfaces: []InterfaceConfig{{InterfaceName: "bat0"}}}
+	assert.Equal(time.Duration(0), c.dedupWindow())
+
+	c.Interfaces[0].Priority = 1
+	assert.Equal(dedupWindowDefault, c.dedupWindow())
+
+	c.DedupWindow.Duration = time.Second * 3
+	assert.Equal(time.Second*3, c.dedupWindow())
+}
@@ -0,0 +1,81 @@
+package respond
+
+import (
+	"log"
+	"net"
+	"sync/atomic"
+
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// sourceAllowed checks the source address against the allowed prefixes of the receiving interface,
+// responses of unknown interfaces (e.g. replayed) are allowed
+func (coll *Collector) sourceAllowed(ifname string, ip net.IP) bool {
+	coll.interfaceLock.RLock()
+	defer coll.interfaceLock.RUnlock()
+	for _, iface := range coll.interfaces {
+		if iface.config.InterfaceName != ifname || len(iface.allowed) == 0 {
+			continue
+		}
+		for _, prefix := range iface.allowed {
+			if prefix.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// usualAddress returns the address of the node, if it is online and answers from another IP address,
+// unknown and offline nodes may answer from any address.
+// The zone is the receiving interface, a node may answer on every interface of the mesh (e.g. multicast and unicast).
+func (coll *Collector) usualAddress(nodeID string, addr *net.UDPAddr) *net.UDPAddr {
+	coll.nodes.RLock()
+	defer coll.nodes.RUnlock()
+	node := coll.nodes.List[nodeID]
+	if node == nil || !node.Online || node.Address == nil {
+		return nil
+	}
+	if node.Address.IP.Equal(addr.IP) {
+		return nil
+	}
+	return node.Address
+}
+
+// checkSource returns false and quarantines the response,
+// if the source address is not allowed or the node usually answers from another address
+func (coll *Collector) checkSource(nodeID string, res *Response) bool {
+	if res.Address == nil {
+		return true
+	}
+	entry := runtime.QuarantineEntry{
+		Timestamp: jsontime.Now(),
+		Interface: res.Interface,
+		Address:   res.Address.String(),
+		NodeID:    nodeID,
+	}
+
+	if !coll.sourceAllowed(res.Interface, res.Address.IP) {
+		entry.Reason = runtime.RejectSourceNotAllowed
+	} else if coll.addressGuard {
+		usual := coll.usualAddress(nodeID, res.Address)
+		if usual == nil {
+			return true
+		}
+		entry.Reason = runtime.RejectAddressChanged
+		entry.Usual = usual.String()
+	} else {
+		return true
+	}
+
+	if entry.Usual != "" {
+		log.Printf("rejected response of %s from %s: %s (usually from %s)", nodeID, entry.Address, entry.Reason, entry.Usual)
+	} else {
+		log.Printf("rejected response of %s from %s: %s", nodeID, entry.Address, entry.Reason)
+	}
+	atomic.AddUint64(&coll.stats.rejected, 1)
+	coll.nodes.Quarantine(entry)
+	return false
+}
@@ -0,0 +1,109 @@
+package respond
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestSourceAllowed(t *testing.T) {
+	assert := assert.New(t)
+
+	iface := InterfaceConfig{InterfaceName: "bat0", AllowedSources: []string{"fe80::/64", "10.0.0.1"}}
+	allowed, err := iface.allowedSources()
+	assert.NoError(err)
+	coll := &Collector{
+		nodes: runtime.NewNodes(&runtime.NodesConfig{}),
+		stats: newCollectorStats(),
+		interfaces: []*collectorInterface{
+			{config: iface, allowed: allowed},
+			{config: InterfaceConfig{InterfaceName: "br-ffhb"}},
+		},
+	}
+
+	assert.True(coll.sourceAllowed("bat0", net.ParseIP("fe80::1")))
+	assert.True(coll.sourceAllowed("bat0", net.ParseIP("10.0.0.1")))
+	assert.False(coll.sourceAllowed("bat0", net.ParseIP("10.0.0.2")))
+	assert.False(coll.sourceAllowed("bat0", net.ParseIP("2001:db8::1")))
+	assert.True(coll.sourceAllowed("br-ffhb", net.ParseIP("2001:db8::1")), "without allowed sources")
+	assert.True(coll.sourceAllowed("", net.ParseIP("2001:db8::1")), "unknown interface")
+
+	res := &Response{Interface: "bat0", Address: &net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 1001}}
+	assert.False(coll.checkSource("f81a67a601ea", res))
+	assert.EqualValues(1, coll.stats.get().Rejected)
+	quarantine := coll.nodes.GetQuarantine()
+	assert.Len(quarantine, 1)
+	assert.Equal(runtime.RejectSourceNotAllowed, quarantine[0].Reason)
+	assert.Equal("f81a67a601ea", quarantine[0].NodeID)
+	assert.Equal("[2001:db8::1]:1001", quarantine[0].Address)
+	assert.Equal("bat0", quarantine[0].Interface)
+}
+
+func TestAddressGuard(t *testing.T) {
+	assert := assert.New(t)
+
+	coll := &Collector{
+		nodes:        runtime.NewNodes(&runtime.NodesConfig{}),
+		stats:        newCollectorStats(),
+		addressGuard: true,
+	}
+	usual := &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001, Zone: "bat0"}
+	other := &net.UDPAddr{IP: net.ParseIP("fe80::2"), Port: 1001, Zone: "bat0"}
+	otherZone := &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001, Zone: "br-ffhb"}
+
+	// unknown nodes may answer from any address
+	assert.True(coll.checkSource("f81a67a601ea", &Response{Address: other}))
+
+	node := coll.nodes.Update("f81a67a601ea", &data.ResponseData{})
+	node.Address = usual
+	assert.True(coll.checkSource("f81a67a601ea", &Response{Address: &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1002, Zone: "bat0"}}))
+	assert.False(coll.checkSource("f81a67a601ea", &Response{Address: other}))
+	assert.True(coll.checkSource("f81a67a601ea", &Response{Address: otherZone}), "same address on another interface")
+
+	quarantine := coll.nodes.GetQuarantine()
+	assert.Len(quarantine, 1)
+	assert.Equal(runtime.RejectAddressChanged, quarantine[0].Reason)
+	assert.Equal("[fe80::1%bat0]:1001", quarantine[0].Usual)
+
+	// an offline node may answer from another address
+	node.Online = false
+	assert.True(coll.checkSource("f81a67a601ea", &Response{Address: other}))
+	assert.EqualValues(1, coll.stats.get().Rejected)
+
+	// without the guard
+	coll.addressGuard = false
+	node.Online = true
+	assert.True(coll.checkSource("f81a67a601ea", &Response{Address: other}))
+}
+
+func TestAddressGuardInterfaces(t *testing.T) {
+	assert := assert.New(t)
+
+	compressed, err := ioutil.ReadFile("testdata/nodeinfo.flated")
+	assert.NoError(err)
+
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	collector := NewCollector(nil, nodes, &Config{AddressGuard: true})
+
+	// the node answers on both interfaces of the mesh
+	for _, ifname := range []string{"bat0", "br-ffhb", "bat0"} {
+		collector.Replay(&Response{
+			Address:   &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001, Zone: ifname},
+			Interface: ifname,
+			Timestamp: time.Now(),
+			Raw:       compressed,
+		})
+	}
+	collector.Close()
+
+	assert.Contains(nodes.List, "f81a67a5e9c1")
+	assert.True(nodes.List["f81a67a5e9c1"].Online)
+	assert.Empty(nodes.GetQuarantine())
+	assert.EqualValues(0, collector.Stats().Rejected)
+}
@@ -0,0 +1,389 @@
+package respond
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+const (
+	interfaceCheckInterval = 5 * time.Second // how often the interfaces are checked for changes
+	interfaceBackoffMin    = 5 * time.Second // first delay to retry a failed bind
+	interfaceBackoffMax    = 5 * time.Minute
+)
+
+// collectorInterface is a configured interface with its UDP socket, if it is bound
+type collectorInterface struct {
+	config  InterfaceConfig
+	conn    *multicastConn // nil while the interface is not bound
+	index   int            // index of the network interface at bind time
+	err     error          // last error of binding or watching
+	binds   uint64
+	retry   time.Time     // next try to bind
+	backoff time.Duration // delay after the next failed bind
+	allowed []*net.IPNet  // allowed source prefixes, empty for all
+}
+
+// validate checks the configuration which does not depend on the state of the interface
+func (iface InterfaceConfig) validate() error {
+	if iface.IPAddress != "" && net.ParseIP(iface.IPAddress) == nil {
+		return fmt.Errorf("invalid ip_address '%s' of interface %s", iface.IPAddress, iface.InterfaceName)
+	}
+	if iface.MulticastAddress != "" && net.ParseIP(iface.MulticastAddress) == nil {
+		return fmt.Errorf("invalid multicast_address '%s' of interface %s", iface.MulticastAddress, iface.InterfaceName)
+	}
+	if iface.IPAddress == "" && iface.InterfaceName == "" {
+		return fmt.Errorf("interface needs an ifname or an ip_address")
+	}
+	_, err := iface.allowedSources()
+	return err
+}
+
+// allowedSources parses the allowed source prefixes, a single address is a prefix of full length
+func (iface InterfaceConfig) allowedSources() ([]*net.IPNet, error) {
+	var prefixes []*net.IPNet
+	for _, source := range iface.AllowedSources {
+		if ip := net.ParseIP(source); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			prefixes = append(prefixes, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, prefix, err := net.ParseCIDR(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed_sources '%s' of interface %s", source, iface.InterfaceName)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// sections returns the sections to request on the interface
+func (iface InterfaceConfig) sections() []string {
+	if len(iface.Sections) == 0 {
+		return sectionsDefault
+	}
+	return iface.Sections
+}
+
+// listenUDP opens the UDP socket of the given interface
+func listenUDP(iface InterfaceConfig) (*multicastConn, error) {
+
+	var addr net.IP
+
+	multicastAddress := net.ParseIP(multicastAddressDefault)
+	if iface.MulticastAddress != "" {
+		multicastAddress = net.ParseIP(iface.MulticastAddress)
+	}
+
+	var err error
+	if iface.IPAddress != "" {
+		addr = net.ParseIP(iface.IPAddress)
+	} else if multicastAddress.To4() != nil {
+		// IPv4 multicast group or broadcast address
+		addr, err = getIPv4Addr(iface.InterfaceName)
+	} else {
+		addr, err = getUnicastAddr(iface.InterfaceName, iface.MulticastAddress == "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Open socket
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{
+		IP:   addr,
+		Port: iface.Port,
+		Zone: iface.InterfaceName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadBuffer(maxDataGramSize)
+
+	return &multicastConn{
+		Conn:             conn,
+		InterfaceName:    iface.InterfaceName,
+		SendRequest:      !iface.SendNoRequest,
+		MulticastAddress: multicastAddress,
+		Sections:         iface.sections(),
+	}, nil
+}
+
+// getIPv4Addr returns an IPv4 address of the given interface
+func getIPv4Addr(ifname string) (net.IP, error) {
+	iface, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addresses {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return ipnet.IP.To4(), nil
+		}
+	}
+	return nil, fmt.Errorf("unable to find an IPv4 address for %s", ifname)
+}
+
+// Returns a unicast address of given interface (linklocal or global unicast address)
+func getUnicastAddr(ifname string, linklocal bool) (net.IP, error) {
+	iface, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var ip net.IP
+
+	for _, addr := range addresses {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if (!linklocal && ipnet.IP.IsGlobalUnicast()) || (linklocal && ipnet.IP.IsLinkLocalUnicast()) {
+			ip = ipnet.IP
+		}
+	}
+	if ip != nil {
+		return ip, nil
+	}
+	return nil, fmt.Errorf("unable to find a unicast address for %s", ifname)
+}
+
+// newInterface creates the interface and tries to bind it
+func (coll *Collector) newInterface(config InterfaceConfig, now time.Time) *collectorInterface {
+	iface := &collectorInterface{config: config}
+	iface.allowed, _ = config.allowedSources() // checked by validate
+	coll.bind(iface, now)
+	return iface
+}
+
+// bind opens the UDP socket of the interface,
+// on errors the next try is delayed by an exponential backoff
+func (coll *Collector) bind(iface *collectorInterface, now time.Time) {
+	conn, err := listenUDP(iface.config)
+	if err != nil {
+		if iface.backoff == 0 {
+			iface.backoff = interfaceBackoffMin
+		}
+		iface.err = err
+		iface.retry = now.Add(iface.backoff)
+		log.Printf("unable to bind interface %s, retrying in %s: %s", iface.config.InterfaceName, iface.backoff, err)
+
+		iface.backoff *= 2
+		if iface.backoff > interfaceBackoffMax {
+			iface.backoff = interfaceBackoffMax
+		}
+		return
+	}
+
+	iface.index = 0
+	if iface.config.InterfaceName != "" {
+		if netIface, err := net.InterfaceByName(iface.config.InterfaceName); err == nil {
+			iface.index = netIface.Index
+		}
+	}
+	iface.conn = conn
+	iface.err = nil
+	iface.backoff = 0
+	iface.binds++
+	log.Printf("listening on interface %s with %s", iface.config.InterfaceName, conn.Conn.LocalAddr())
+
+	coll.receiverWG.Add(1)
+	go coll.receiver(conn.Conn, conn.InterfaceName)
+}
+
+// changed returns an error if the network interface of the bound socket
+// was removed, recreated or lost the address of the socket
+func (iface *collectorInterface) changed() error {
+	if iface.config.InterfaceName == "" {
+		return nil
+	}
+	netIface, err := net.InterfaceByName(iface.config.InterfaceName)
+	if err != nil {
+		return err
+	}
+	if netIface.Index != iface.index {
+		return fmt.Errorf("interface %s was recreated", iface.config.InterfaceName)
+	}
+
+	local := iface.conn.Conn.LocalAddr().(*net.UDPAddr).IP
+	if local.IsUnspecified() {
+		return nil
+	}
+	addresses, err := netIface.Addrs()
+	if err != nil {
+		return err
+	}
+	for _, addr := range addresses {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(local) {
+			return nil
+		}
+	}
+	return fmt.Errorf("address %s was removed from interface %s", local, iface.config.InterfaceName)
+}
+
+// close closes the UDP socket of the interface
+func (iface *collectorInterface) close() {
+	if iface.conn != nil {
+		iface.conn.Conn.Close()
+		iface.conn = nil
+	}
+}
+
+// check rebinds the interface if it changed and retries failed binds
+func (coll *Collector) check(iface *collectorInterface, now time.Time) {
+	if iface.conn != nil {
+		err := iface.changed()
+		if err == nil {
+			return
+		}
+		log.Printf("interface %s changed, rebinding: %s", iface.config.InterfaceName, err)
+		iface.close()
+		iface.err = err
+		iface.retry = now
+	}
+	if now.Before(iface.retry) {
+		return
+	}
+	coll.bind(iface, now)
+}
+
+// checkInterfaces checks all interfaces once
+func (coll *Collector) checkInterfaces(now time.Time) {
+	coll.interfaceLock.Lock()
+	defer coll.interfaceLock.Unlock()
+	if coll.interfacesDone {
+		return
+	}
+	for _, iface := range coll.interfaces {
+		coll.check(iface, now)
+	}
+}
+
+// watch the interfaces continuously
+func (coll *Collector) interfaceWatcher() {
+	ticker := time.NewTicker(interfaceCheckInterval)
+	for {
+		select {
+		case <-coll.stop:
+			ticker.Stop()
+			return
+		case now := <-ticker.C:
+			coll.checkInterfaces(now)
+		}
+	}
+}
+
+// SetInterfaces replaces the configured interfaces.
+// Sockets of unchanged interfaces are kept open and new interfaces
+// which are not able to bind yet are retried by the watcher.
+// If any configuration is invalid the current interfaces stay in use.
+func (coll *Collector) SetInterfaces(configs []InterfaceConfig) error {
+	for _, config := range configs {
+		if err := config.validate(); err != nil {
+			return err
+		}
+	}
+
+	coll.interfaceLock.Lock()
+	defer coll.interfaceLock.Unlock()
+	if coll.interfacesDone {
+		return fmt.Errorf("collector is closed")
+	}
+
+	now := time.Now()
+	kept := make([]bool, len(coll.interfaces))
+	var interfaces []*collectorInterface
+
+	for _, config := range configs {
+		var found *collectorInterface
+		for i, iface := range coll.interfaces {
+			if !kept[i] && reflect.DeepEqual(iface.config, config) {
+				kept[i] = true
+				found = iface
+				break
+			}
+		}
+		if found == nil {
+			found = coll.newInterface(config, now)
+		}
+		interfaces = append(interfaces, found)
+	}
+
+	for i, iface := range coll.interfaces {
+		if !kept[i] {
+			iface.close()
+		}
+	}
+	coll.interfaces = interfaces
+	return nil
+}
+
+// closeInterfaces closes all UDP sockets and stops rebinding them
+func (coll *Collector) closeInterfaces() {
+	coll.interfaceLock.Lock()
+	defer coll.interfaceLock.Unlock()
+	coll.interfacesDone = true
+	for _, iface := range coll.interfaces {
+		iface.close()
+	}
+}
+
+// getConnections returns a copy of the currently bound UDP sockets
+func (coll *Collector) getConnections() []multicastConn {
+	coll.interfaceLock.RLock()
+	defer coll.interfaceLock.RUnlock()
+	var connections []multicastConn
+	for _, iface := range coll.interfaces {
+		if iface.conn != nil {
+			connections = append(connections, *iface.conn)
+		}
+	}
+	return connections
+}
+
+// interfacePriority returns the configured priority of the interface
+func (coll *Collector) interfacePriority(ifname string) int {
+	coll.interfaceLock.RLock()
+	defer coll.interfaceLock.RUnlock()
+	for _, iface := range coll.interfaces {
+		if iface.config.InterfaceName == ifname {
+			return iface.config.Priority
+		}
+	}
+	return 0
+}
+
+// interfaceStats adds the state of the interfaces to the stats
+func (coll *Collector) interfaceStats(stats *runtime.CollectorStats) {
+	coll.interfaceLock.RLock()
+	defer coll.interfaceLock.RUnlock()
+	for _, iface := range coll.interfaces {
+		ifaceStats := stats.Interfaces[iface.config.InterfaceName]
+		if ifaceStats == nil {
+			ifaceStats = &runtime.InterfaceStats{}
+			stats.Interfaces[iface.config.InterfaceName] = ifaceStats
+		}
+		ifaceStats.Up = iface.conn != nil
+		if ifaceStats.Up {
+			ifaceStats.Address = iface.conn.Conn.LocalAddr().String()
+		}
+		if iface.err != nil {
+			ifaceStats.Error = iface.err.Error()
+		}
+		ifaceStats.Binds = iface.binds
+	}
+}
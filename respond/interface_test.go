@@ -0,0 +1,118 @@
+package respond
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestInterfaceValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(InterfaceConfig{InterfaceName: "lo"}.validate())
+	assert.NoError(InterfaceConfig{IPAddress: "::1"}.validate())
+	assert.Error(InterfaceConfig{}.validate())
+	assert.Error(InterfaceConfig{InterfaceName: "lo", IPAddress: "no-ip"}.validate())
+	assert.Error(InterfaceConfig{InterfaceName: "lo", MulticastAddress: "no-ip"}.validate())
+	assert.NoError(InterfaceConfig{InterfaceName: "lo", AllowedSources: []string{"fe80::/64", "10.0.0.1"}}.validate())
+	assert.Error(InterfaceConfig{InterfaceName: "lo", AllowedSources: []string{"fe80::/129"}}.validate())
+}
+
+func TestInterfaceBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	collector := NewCollector(nil, runtime.NewNodes(&runtime.NodesConfig{}), &Config{
+		Interfaces: []InterfaceConfig{{InterfaceName: "yanic-missing0"}},
+	})
+	defer collector.Close()
+
+	iface := collector.interfaces[0]
+	assert.Nil(iface.conn)
+	assert.Error(iface.err)
+	assert.Len(collector.getConnections(), 0)
+
+	stats := collector.Stats().Interfaces["yanic-missing0"]
+	assert.False(stats.Up)
+	assert.NotEmpty(stats.Error)
+
+	// no retry before the backoff
+	start := iface.retry
+	collector.checkInterfaces(start.Add(-time.Second))
+	assert.Equal(start, iface.retry)
+
+	// the delay doubles on every failed retry
+	collector.checkInterfaces(start)
+	assert.Equal(start.Add(2*interfaceBackoffMin), iface.retry)
+	for i := 0; i < 10; i++ {
+		collector.checkInterfaces(iface.retry)
+	}
+	assert.Equal(interfaceBackoffMax, iface.backoff)
+
+	// a fixed configuration binds
+	iface.config = InterfaceConfig{InterfaceName: "lo", IPAddress: "127.0.0.1"}
+	collector.checkInterfaces(iface.retry)
+	assert.NotNil(iface.conn)
+	assert.NoError(iface.err)
+	assert.Len(collector.getConnections(), 1)
+
+	// changes of the interface rebind the socket
+	iface.index = -1
+	collector.checkInterfaces(time.Now())
+	assert.NotNil(iface.conn)
+	assert.Equal(uint64(2), iface.binds)
+
+	stats = collector.Stats().Interfaces["lo"]
+	assert.True(stats.Up)
+	assert.Contains(stats.Address, "127.0.0.1")
+}
+
+func TestSetInterfaces(t *testing.T) {
+	assert := assert.New(t)
+
+	lo := InterfaceConfig{InterfaceName: "lo", IPAddress: "127.0.0.1"}
+	collector := NewCollector(nil, runtime.NewNodes(&runtime.NodesConfig{}), &Config{
+		Interfaces: []InterfaceConfig{lo},
+	})
+	kept := collector.getConnections()[0].Conn
+
+	// an invalid interface keeps the current interfaces
+	err := collector.SetInterfaces([]InterfaceConfig{lo, {InterfaceName: "lo", IPAddress: "no-ip"}})
+	assert.Error(err)
+	assert.Len(collector.interfaces, 1)
+
+	// a missing interface is retried later
+	err = collector.SetInterfaces([]InterfaceConfig{lo, {InterfaceName: "yanic-missing0"}})
+	assert.NoError(err)
+	assert.Len(collector.interfaces, 2)
+	assert.Len(collector.getConnections(), 1)
+
+	err = collector.SetInterfaces([]InterfaceConfig{{InterfaceName: "lo", IPAddress: "::1"}, lo})
+	assert.NoError(err)
+	connections := collector.getConnections()
+	assert.Len(connections, 2)
+	assert.Equal(kept, connections[1].Conn, "unchanged interface keeps its socket")
+
+	err = collector.SetInterfaces(nil)
+	assert.NoError(err)
+	assert.Len(collector.getConnections(), 0)
+
+	collector.Close()
+	assert.Error(collector.SetInterfaces([]InterfaceConfig{lo}))
+}
+
+func TestListenIPv4Broadcast(t *testing.T) {
+	assert := assert.New(t)
+
+	conn, err := listenUDP(InterfaceConfig{InterfaceName: "lo", MulticastAddress: "127.255.255.255"})
+	assert.NoError(err)
+	defer conn.Conn.Close()
+	assert.Equal("127.0.0.1", conn.Conn.LocalAddr().(*net.UDPAddr).IP.String())
+	assert.Equal("127.255.255.255", conn.MulticastAddress.String())
+
+	_, err = getIPv4Addr("yanic-missing0")
+	assert.Error(err)
+}
@@ -1,9 +1,17 @@
 package respond
 
 import (
+	"bytes"
 	"net"
+	"strings"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
 )
 
+// Source is the label of nodes updated by the collector
+const Source = "respondd"
+
 const (
 	// default multicast group used by announced
 	multicastAddressDefault = "ff02:0:0:0:0:0:2:1001"
@@ -15,8 +23,41 @@ const (
 	maxDataGramSize = 8192
 )
 
+// Formats of the response payload
+const (
+	formatDeflate = iota // raw deflate, e.g. gluon
+	formatJSON           // plain JSON
+	formatGzip
+)
+
+// default sections to request
+var sectionsDefault = []string{data.SectionNodeInfo, data.SectionStatistics, data.SectionNeighbours}
+
 // Response of the respond request
 type Response struct {
-	Address *net.UDPAddr
-	Raw     []byte
+	Address   *net.UDPAddr
+	Interface string    // name of the receiving interface
+	Timestamp time.Time // time of receiving
+	Raw       []byte
+}
+
+// payloadFormat detects the format of a response,
+// raw deflate has no header, so everything which is neither JSON nor gzip is deflate
+func payloadFormat(raw []byte) int {
+	trimmed := bytes.TrimLeft(raw, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return formatJSON
+	}
+	if len(raw) >= 2 && raw[0] == 0x1f && raw[1] == 0x8b {
+		return formatGzip
+	}
+	return formatDeflate
+}
+
+// requestPacket returns the payload to request the given sections
+func requestPacket(sections []string) []byte {
+	if len(sections) == 0 {
+		sections = sectionsDefault
+	}
+	return []byte("GET " + strings.Join(sections, " "))
 }
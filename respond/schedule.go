@@ -0,0 +1,71 @@
+package respond
+
+import (
+	"sync"
+	"time"
+)
+
+// sectionSchedule decides which sections are requested in a round,
+// every section is requested again after its interval
+type sectionSchedule struct {
+	defaultInterval time.Duration
+	intervals       map[string]time.Duration
+
+	sync.Mutex
+	round       time.Time            // start of the current round
+	lastRequest map[string]time.Time // start of the round in which a section was requested last
+}
+
+func newSectionSchedule(defaultInterval time.Duration, intervals map[string]time.Duration) *sectionSchedule {
+	return &sectionSchedule{
+		defaultInterval: defaultInterval,
+		intervals:       intervals,
+		lastRequest:     make(map[string]time.Time),
+	}
+}
+
+// interval returns the interval of the given section
+func (s *sectionSchedule) interval(section string) time.Duration {
+	if interval := s.intervals[section]; interval > 0 {
+		return interval
+	}
+	return s.defaultInterval
+}
+
+// tick returns the time between two rounds, the shortest interval of all sections
+func (s *sectionSchedule) tick() time.Duration {
+	tick := s.defaultInterval
+	for _, interval := range s.intervals {
+		if interval > 0 && (tick <= 0 || interval < tick) {
+			tick = interval
+		}
+	}
+	return tick
+}
+
+// newRound starts a new round
+func (s *sectionSchedule) newRound(start time.Time) {
+	s.Lock()
+	s.round = start
+	s.Unlock()
+}
+
+// due returns the given sections which are requested in the current round
+func (s *sectionSchedule) due(sections []string) []string {
+	s.Lock()
+	defer s.Unlock()
+
+	// rounds are not exactly one tick apart
+	tolerance := s.tick() / 2
+
+	var result []string
+	for _, section := range sections {
+		last, ok := s.lastRequest[section]
+		if ok && !last.Equal(s.round) && s.round.Sub(last) < s.interval(section)-tolerance {
+			continue
+		}
+		s.lastRequest[section] = s.round
+		result = append(result, section)
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package respond
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSectionSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	schedule := newSectionSchedule(time.Minute, map[string]time.Duration{
+		"nodeinfo": 3 * time.Minute,
+	})
+	assert.Equal(time.Minute, schedule.tick())
+	sections := []string{"nodeinfo", "statistics", "neighbours"}
+
+	start := time.Now()
+	schedule.newRound(start)
+	assert.Equal(sections, schedule.due(sections))
+	// further requests in the same round
+	assert.Equal(sections, schedule.due(sections))
+
+	var nodeinfo int
+	for i := 1; i <= 6; i++ {
+		// rounds are not exactly one tick apart
+		schedule.newRound(start.Add(time.Duration(i)*time.Minute - time.Second))
+		due := schedule.due(sections)
+		assert.Contains(due, "statistics")
+		assert.Contains(due, "neighbours")
+		for _, section := range due {
+			if section == "nodeinfo" {
+				nodeinfo++
+			}
+		}
+	}
+	assert.Equal(2, nodeinfo)
+
+	// a section with shorter interval speeds up the rounds
+	schedule = newSectionSchedule(time.Minute, map[string]time.Duration{
+		"statistics": 30 * time.Second,
+	})
+	assert.Equal(30*time.Second, schedule.tick())
+	schedule.newRound(start)
+	assert.Equal(sections, schedule.due(sections))
+	schedule.newRound(start.Add(30 * time.Second))
+	assert.Equal([]string{"statistics"}, schedule.due(sections))
+	assert.Len(schedule.due([]string{"nodeinfo"}), 0)
+}
@@ -0,0 +1,146 @@
+package respond
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+// collectorStats counts the self-metrics of the collector
+type collectorStats struct {
+	// 64-bit counters are accessed atomically and have to be aligned
+	decodeErrors     uint64
+	invalidNodeIDs   uint64
+	nodeIDMismatches uint64
+	responses        uint64
+	duplicates       uint64
+	rejected         uint64
+	multicastsSent   uint64
+	unicastsSent     uint64
+	queueDropped     uint64
+	databaseDropped  uint64
+
+	sync.Mutex
+	interfaces map[string]*runtime.InterfaceStats
+
+	// latency of the current and the last complete round
+	roundStart      time.Time
+	roundUnicasts   map[string]time.Time // send time of unicast requests, indexed by address
+	roundResponses  uint64
+	roundLatencySum time.Duration
+	roundLatencyMax time.Duration
+	lastRound       runtime.CollectorStats
+}
+
+func newCollectorStats() *collectorStats {
+	return &collectorStats{
+		interfaces:    make(map[string]*runtime.InterfaceStats),
+		roundUnicasts: make(map[string]time.Time),
+	}
+}
+
+func (s *collectorStats) iface(ifname string) *runtime.InterfaceStats {
+	stats := s.interfaces[ifname]
+	if stats == nil {
+		stats = &runtime.InterfaceStats{}
+		s.interfaces[ifname] = stats
+	}
+	return stats
+}
+
+// received counts a datagram of the interface
+func (s *collectorStats) received(ifname string) {
+	s.Lock()
+	s.iface(ifname).Received++
+	s.Unlock()
+}
+
+// requestSent counts a request and remembers the send time of unicasts,
+// group is set for requests to a multicast or broadcast address
+func (s *collectorStats) requestSent(ifname string, addr *net.UDPAddr, group bool) {
+	if group {
+		atomic.AddUint64(&s.multicastsSent, 1)
+	} else {
+		atomic.AddUint64(&s.unicastsSent, 1)
+	}
+
+	s.Lock()
+	s.iface(ifname).RequestsSent++
+	if !group && !s.roundStart.IsZero() {
+		s.roundUnicasts[addr.IP.String()] = time.Now()
+	}
+	s.Unlock()
+}
+
+// newRound completes the current round and starts a new one
+func (s *collectorStats) newRound(start time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.roundStart.IsZero() {
+		s.lastRound.RoundResponses = s.roundResponses
+		s.lastRound.RoundLatencyMax = s.roundLatencyMax
+		s.lastRound.RoundLatencyAvg = 0
+		if s.roundResponses > 0 {
+			s.lastRound.RoundLatencyAvg = s.roundLatencySum / time.Duration(s.roundResponses)
+		}
+	}
+
+	s.roundStart = start
+	s.roundUnicasts = make(map[string]time.Time)
+	s.roundResponses = 0
+	s.roundLatencySum = 0
+	s.roundLatencyMax = 0
+}
+
+// response counts a stored response and its latency to the request of the current round
+func (s *collectorStats) response(res *Response) {
+	atomic.AddUint64(&s.responses, 1)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.roundStart.IsZero() || res.Timestamp.Before(s.roundStart) {
+		return
+	}
+	sent := s.roundStart
+	if res.Address != nil {
+		if unicast, ok := s.roundUnicasts[res.Address.IP.String()]; ok && !res.Timestamp.Before(unicast) {
+			sent = unicast
+		}
+	}
+
+	latency := res.Timestamp.Sub(sent)
+	s.roundResponses++
+	s.roundLatencySum += latency
+	if latency > s.roundLatencyMax {
+		s.roundLatencyMax = latency
+	}
+}
+
+// get returns a snapshot of all metrics
+func (s *collectorStats) get() *runtime.CollectorStats {
+	s.Lock()
+	defer s.Unlock()
+
+	stats := s.lastRound
+	stats.Interfaces = make(map[string]*runtime.InterfaceStats, len(s.interfaces))
+	for ifname, ifaceStats := range s.interfaces {
+		copied := *ifaceStats
+		stats.Interfaces[ifname] = &copied
+	}
+	stats.DecodeErrors = atomic.LoadUint64(&s.decodeErrors)
+	stats.InvalidNodeIDs = atomic.LoadUint64(&s.invalidNodeIDs)
+	stats.NodeIDMismatches = atomic.LoadUint64(&s.nodeIDMismatches)
+	stats.Responses = atomic.LoadUint64(&s.responses)
+	stats.Duplicates = atomic.LoadUint64(&s.duplicates)
+	stats.Rejected = atomic.LoadUint64(&s.rejected)
+	stats.MulticastsSent = atomic.LoadUint64(&s.multicastsSent)
+	stats.UnicastsSent = atomic.LoadUint64(&s.unicastsSent)
+	stats.QueueDropped = atomic.LoadUint64(&s.queueDropped)
+	stats.DatabaseDropped = atomic.LoadUint64(&s.databaseDropped)
+	return &stats
+}
@@ -0,0 +1,82 @@
+package respond
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestCollectorStats(t *testing.T) {
+	assert := assert.New(t)
+	stats := newCollectorStats()
+
+	start := time.Now()
+	stats.newRound(start)
+	stats.received("br-ffhb")
+	stats.requestSent("br-ffhb", &net.UDPAddr{IP: net.ParseIP(multicastAddressDefault)}, true)
+	stats.requestSent("br-ffhb", &net.UDPAddr{IP: net.ParseIP("fe80::1")}, false)
+
+	// multicast response
+	stats.response(&Response{
+		Address:   &net.UDPAddr{IP: net.ParseIP("fe80::2")},
+		Timestamp: start.Add(time.Second),
+	})
+	// unicast response
+	stats.response(&Response{
+		Address:   &net.UDPAddr{IP: net.ParseIP("fe80::1")},
+		Timestamp: time.Now().Add(time.Second * 3),
+	})
+	// response of an older round
+	stats.response(&Response{
+		Timestamp: start.Add(-time.Second),
+	})
+
+	result := stats.get()
+	assert.EqualValues(3, result.Responses)
+	assert.EqualValues(1, result.MulticastsSent)
+	assert.EqualValues(1, result.UnicastsSent)
+	assert.EqualValues(1, result.Interfaces["br-ffhb"].Received)
+	assert.EqualValues(2, result.Interfaces["br-ffhb"].RequestsSent)
+	// round not complete
+	assert.EqualValues(0, result.RoundResponses)
+
+	stats.newRound(time.Now())
+	result = stats.get()
+	assert.EqualValues(2, result.RoundResponses)
+	assert.True(result.RoundLatencyMax >= time.Second*3)
+	assert.True(result.RoundLatencyAvg >= time.Second*2)
+
+	// empty round
+	stats.newRound(time.Now())
+	result = stats.get()
+	assert.EqualValues(0, result.RoundResponses)
+	assert.EqualValues(0, result.RoundLatencyAvg)
+}
+
+func TestCollectorStatsBroadcast(t *testing.T) {
+	assert := assert.New(t)
+
+	collector := NewCollector(nil, runtime.NewNodes(&runtime.NodesConfig{}), &Config{})
+	defer collector.Close()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.NoError(err)
+	defer conn.Close()
+	local := conn.LocalAddr().(*net.UDPAddr)
+
+	// the configured (broadcast) address of the interface
+	iface := &multicastConn{Conn: conn, InterfaceName: "lo", MulticastAddress: local.IP, Sections: sectionsDefault}
+	collector.stats.newRound(time.Now())
+	collector.writeRequest(iface, local)
+	collector.writeRequest(iface, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: local.Port})
+
+	stats := collector.Stats()
+	assert.EqualValues(1, stats.MulticastsSent)
+	assert.EqualValues(1, stats.UnicastsSent)
+	assert.NotContains(collector.stats.roundUnicasts, local.IP.String())
+	assert.Contains(collector.stats.roundUnicasts, "127.0.0.2")
+}
@@ -0,0 +1,72 @@
+package respond
+
+import (
+	"fmt"
+	"net"
+)
+
+// maximum count of addresses a target prefix may contain
+const maxTargetPrefixAddresses = 1024
+
+// target is a statically configured address which is polled by unicast
+type target struct {
+	key    string // the key of the reachability state in runtime.Nodes
+	ip     net.IP // nil for hostnames
+	host   string
+	port   int
+	ifname string
+}
+
+// newTargets parses a target configuration, prefixes are expanded to a target per address
+func newTargets(config TargetConfig) ([]*target, error) {
+	destinationPort := config.Port
+	if destinationPort == 0 {
+		destinationPort = port
+	}
+
+	if ip := net.ParseIP(config.Address); ip != nil {
+		return []*target{{key: ip.String(), ip: ip, port: destinationPort, ifname: config.InterfaceName}}, nil
+	}
+
+	if _, ipnet, err := net.ParseCIDR(config.Address); err == nil {
+		ones, bits := ipnet.Mask.Size()
+		if bits-ones > 30 || 1<<uint(bits-ones) > maxTargetPrefixAddresses {
+			return nil, fmt.Errorf("target prefix %s contains more than %d addresses", config.Address, maxTargetPrefixAddresses)
+		}
+		var targets []*target
+		for ip := ipnet.IP; ipnet.Contains(ip); ip = nextIP(ip) {
+			// skip network and broadcast address of IPv4 networks
+			if ip4 := ip.To4(); ip4 != nil && bits-ones > 1 && (ip.Equal(ipnet.IP) || !ipnet.Contains(nextIP(ip))) {
+				continue
+			}
+			targets = append(targets, &target{key: ip.String(), ip: ip, port: destinationPort, ifname: config.InterfaceName})
+		}
+		return targets, nil
+	}
+
+	if config.Address == "" {
+		return nil, fmt.Errorf("target without address")
+	}
+	return []*target{{key: config.Address, host: config.Address, port: destinationPort, ifname: config.InterfaceName}}, nil
+}
+
+// resolve returns the addresses to send the request to
+func (t *target) resolve() ([]net.IP, error) {
+	if t.ip != nil {
+		return []net.IP{t.ip}, nil
+	}
+	return net.LookupIP(t.host)
+}
+
+// nextIP returns the following address
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
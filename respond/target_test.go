@@ -0,0 +1,52 @@
+package respond
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTargets(t *testing.T) {
+	assert := assert.New(t)
+
+	targets, err := newTargets(TargetConfig{Address: "2001:db8::1"})
+	assert.NoError(err)
+	assert.Len(targets, 1)
+	assert.Equal("2001:db8::1", targets[0].key)
+	assert.Equal(port, targets[0].port)
+
+	targets, err = newTargets(TargetConfig{Address: "2001:db8::/126", Port: 10001, InterfaceName: "eth0"})
+	assert.NoError(err)
+	assert.Len(targets, 4)
+	assert.Equal("2001:db8::3", targets[3].key)
+	assert.Equal(10001, targets[3].port)
+	assert.Equal("eth0", targets[3].ifname)
+
+	// without network and broadcast address
+	targets, err = newTargets(TargetConfig{Address: "10.0.0.0/29"})
+	assert.NoError(err)
+	assert.Len(targets, 6)
+	assert.Equal("10.0.0.1", targets[0].key)
+	assert.Equal("10.0.0.6", targets[5].key)
+
+	targets, err = newTargets(TargetConfig{Address: "localhost"})
+	assert.NoError(err)
+	assert.Len(targets, 1)
+	assert.Equal("localhost", targets[0].host)
+	ips, err := targets[0].resolve()
+	assert.NoError(err)
+	assert.NotEmpty(ips)
+
+	_, err = newTargets(TargetConfig{Address: "2001:db8::/64"})
+	assert.Error(err)
+
+	_, err = newTargets(TargetConfig{})
+	assert.Error(err)
+}
+
+func TestNextIP(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("10.0.1.0", nextIP([]byte{10, 0, 0, 255}).String())
+	assert.Equal("::1:0", nextIP([]byte{15: 0xff, 14: 0xff}).String())
+}
@@ -0,0 +1,150 @@
+package respond
+
+import (
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+const (
+	unicastRateDefault   = 100              // requests per second and interface
+	unicastGiveUpDefault = time.Minute * 10 // without offline_after
+)
+
+// unicastState is the retry state of a node which did not answer the multicast requests
+type unicastState struct {
+	failures  int       // rounds in a row with an unanswered unicast request
+	requested time.Time // round of the last unicast request, zero if it is evaluated
+	nextRound time.Time // earliest round of the next unicast request
+}
+
+// unicastRequest is a planned unicast request of a round
+type unicastRequest struct {
+	nodeID string
+	addr   *net.UDPAddr
+	offset time.Duration // time after the start of the round
+}
+
+// unicastScheduler spreads the unicast requests of a round with jitter over the interval,
+// nodes which do not answer are requested in exponentially increasing intervals
+// until they are not seen for offline_after
+type unicastScheduler struct {
+	random *rand.Rand
+	nodes  map[string]*unicastState // indexed by NodeID
+}
+
+func newUnicastScheduler(seed int64) *unicastScheduler {
+	return &unicastScheduler{
+		random: rand.New(rand.NewSource(seed)),
+		nodes:  make(map[string]*unicastState),
+	}
+}
+
+// plan returns the unicast requests of the round ordered by time,
+// the requests are spread between a quarter and three quarters of the interval,
+// so the multicast responses are received before.
+// The caller has to hold the lock of the nodes.
+func (s *unicastScheduler) plan(nodes map[string]*runtime.Node, round time.Time, interval, giveUp time.Duration) []*unicastRequest {
+	if giveUp <= 0 {
+		giveUp = unicastGiveUpDefault
+	}
+	start := interval / 4
+	span := int64(interval / 2)
+
+	var requests []*unicastRequest
+	for nodeID, node := range nodes {
+		lastseen := node.Lastseen.GetTime()
+		state := s.nodes[nodeID]
+
+		if node.Address == nil || !lastseen.Before(round) || round.Sub(lastseen) > giveUp {
+			delete(s.nodes, nodeID)
+			continue
+		}
+		if state == nil {
+			state = &unicastState{}
+			s.nodes[nodeID] = state
+		}
+
+		// evaluate the last request
+		if !state.requested.IsZero() {
+			if lastseen.Before(state.requested) {
+				state.failures++
+			} else {
+				state.failures = 0
+			}
+			state.requested = time.Time{}
+		}
+		if round.Before(state.nextRound) {
+			continue
+		}
+
+		// rounds are not exactly one interval apart
+		backoff := interval << uint(state.failures)
+		if backoff > giveUp || backoff <= 0 {
+			backoff = giveUp
+		}
+		state.requested = round
+		state.nextRound = round.Add(backoff - interval/2)
+
+		request := &unicastRequest{nodeID: nodeID, addr: node.Address, offset: start}
+		if span > 0 {
+			request.offset += time.Duration(s.random.Int63n(span))
+		}
+		requests = append(requests, request)
+	}
+
+	// forget removed nodes
+	for nodeID := range s.nodes {
+		if nodes[nodeID] == nil {
+			delete(s.nodes, nodeID)
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].offset < requests[j].offset
+	})
+	return requests
+}
+
+// skipped resets the state of a planned request which was not sent
+func (s *unicastScheduler) skipped(nodeID string) {
+	if state := s.nodes[nodeID]; state != nil {
+		state.requested = time.Time{}
+		state.nextRound = time.Time{}
+	}
+}
+
+// rateLimiter limits the requests per interface
+type rateLimiter struct {
+	interval time.Duration // minimal time between two requests on an interface
+
+	sync.Mutex
+	next map[string]time.Time // indexed by interface name
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	if rate <= 0 {
+		rate = unicastRateDefault
+	}
+	return &rateLimiter{
+		interval: time.Second / time.Duration(rate),
+		next:     make(map[string]time.Time),
+	}
+}
+
+// reserve returns the earliest time at or after now to send a request on the interface
+func (l *rateLimiter) reserve(ifname string, now time.Time) time.Time {
+	l.Lock()
+	defer l.Unlock()
+
+	at := now
+	if next := l.next[ifname]; next.After(at) {
+		at = next
+	}
+	l.next[ifname] = at.Add(l.interval)
+	return at
+}
@@ -0,0 +1,97 @@
+package respond
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestUnicastPlan(t *testing.T) {
+	assert := assert.New(t)
+
+	interval := time.Minute
+	now := jsontime.Now()
+	round := now.GetTime()
+	lastseen := now.Add(-time.Second * 30)
+	addr := &net.UDPAddr{IP: net.ParseIP("fd2f::1")}
+	nodes := map[string]*runtime.Node{
+		"000000000001": {Lastseen: lastseen, Address: addr},
+		"000000000002": {Lastseen: lastseen, Address: addr},
+		"000000000003": {Lastseen: lastseen},                                // without address
+		"000000000004": {Lastseen: now.Add(time.Second), Address: addr},     // seen in this round
+		"000000000005": {Lastseen: lastseen.Add(-time.Hour), Address: addr}, // offline
+	}
+
+	s := newUnicastScheduler(1)
+	requests := s.plan(nodes, round, interval, time.Minute*10)
+	assert.Len(requests, 2)
+	for i, request := range requests {
+		assert.True(request.offset >= interval/4)
+		assert.True(request.offset < interval*3/4)
+		if i > 0 {
+			assert.True(request.offset >= requests[i-1].offset, "ordered by time")
+		}
+	}
+	assert.Len(s.nodes, 2)
+
+	// node 1 answers, node 2 is requested in exponentially increasing intervals
+	rounds := map[string][]int{}
+	for i := 1; i <= 8; i++ {
+		offset := time.Duration(i) * (interval + time.Second)
+		nodes["000000000001"].Lastseen = now.Add(offset - time.Second)
+		round = now.Add(offset).GetTime()
+		for _, request := range s.plan(nodes, round, interval, time.Hour) {
+			rounds[request.nodeID] = append(rounds[request.nodeID], i)
+		}
+	}
+	assert.Equal([]int{1, 2, 3, 4, 5, 6, 7, 8}, rounds["000000000001"])
+	assert.Equal([]int{1, 3, 7}, rounds["000000000002"])
+	assert.Equal(4, s.nodes["000000000002"].failures)
+
+	// give up after offline_after
+	round = round.Add(time.Hour)
+	for _, request := range s.plan(nodes, round, interval, time.Hour) {
+		assert.NotEqual("000000000002", request.nodeID)
+	}
+	assert.Nil(s.nodes["000000000002"])
+
+	// forget removed nodes
+	delete(nodes, "000000000001")
+	s.plan(nodes, round, interval, time.Hour)
+	assert.Empty(s.nodes)
+}
+
+func TestUnicastSkipped(t *testing.T) {
+	assert := assert.New(t)
+
+	round := time.Now()
+	nodes := map[string]*runtime.Node{
+		"000000000001": {Lastseen: jsontime.Now().Add(-time.Second), Address: &net.UDPAddr{IP: net.ParseIP("fd2f::1")}},
+	}
+	s := newUnicastScheduler(1)
+	assert.Len(s.plan(nodes, round, time.Minute, 0), 1)
+	s.skipped("000000000001")
+
+	// a skipped request is not counted as failure
+	assert.Len(s.plan(nodes, round.Add(time.Minute), time.Minute, 0), 1)
+	assert.Equal(0, s.nodes["000000000001"].failures)
+}
+
+func TestRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	l := newRateLimiter(10)
+	assert.Equal(now, l.reserve("bat0", now))
+	assert.Equal(now.Add(time.Millisecond*100), l.reserve("bat0", now))
+	assert.Equal(now.Add(time.Millisecond*200), l.reserve("bat0", now))
+	assert.Equal(now, l.reserve("br-ffhb", now), "limited per interface")
+	assert.Equal(now.Add(time.Second), l.reserve("bat0", now.Add(time.Second)))
+
+	assert.Equal(time.Second/unicastRateDefault, newRateLimiter(0).interval)
+}
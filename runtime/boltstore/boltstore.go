@@ -0,0 +1,225 @@
+// Package boltstore stores the state of the nodes in an embedded key-value database (bbolt),
+// every save writes only the changed nodes
+package boltstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+var (
+	bucketNodes   = []byte("nodes")
+	bucketState   = []byte("state")
+	keyTargets    = []byte("targets")
+	keyQuarantine = []byte("quarantine")
+	keyVersion    = []byte("version")
+)
+
+// openTimeout to wait for the lock of the database file
+const openTimeout = time.Second * 5
+
+// Store of the nodes in bbolt
+type Store struct {
+	runtime.StateStore
+	path string
+	db   *bolt.DB
+}
+
+func init() {
+	runtime.RegisterStateStore("bolt", Open)
+}
+
+// Open opens the database at the state_path, an old (compressed) JSON state file at this path
+// is moved to the path with the suffix .json and migrated on the first load
+func Open(config *runtime.NodesConfig) (runtime.StateStore, error) {
+	store := &Store{path: config.StatePath}
+	if isJSONState(store.path) {
+		if err := os.Rename(store.path, store.jsonPath()); err != nil {
+			return nil, err
+		}
+		log.Printf("[boltstore] moved the JSON state to %s for the migration", store.jsonPath())
+	}
+
+	db, err := bolt.Open(store.path, 0644, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(bucketNodes); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(bucketState)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	store.db = db
+	return store, nil
+}
+
+// jsonPath is the path of the migrated JSON state
+func (store *Store) jsonPath() string {
+	return store.path + ".json"
+}
+
+// isJSONState returns true if the file is a JSON object or compressed by gzip or zstd
+// (the state file of the json state_type)
+func isJSONState(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 64)
+	n, _ := f.Read(head)
+	head = head[:n]
+	return bytes.HasPrefix(bytes.TrimSpace(head), []byte("{")) ||
+		bytes.HasPrefix(head, []byte{0x1f, 0x8b}) ||
+		bytes.HasPrefix(head, []byte{0x28, 0xb5, 0x2f, 0xfd})
+}
+
+// Load reads the nodes of the database,
+// an empty database is filled with the migrated JSON state
+func (store *Store) Load(nodes *runtime.Nodes) error {
+	empty := true
+	err := store.db.View(func(tx *bolt.Tx) error {
+		empty = tx.Bucket(bucketNodes).Stats().KeyN == 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if empty {
+		if _, err := os.Stat(store.jsonPath()); err == nil {
+			return store.migrate(nodes)
+		}
+	}
+
+	state := &runtime.State{Nodes: make(map[string]json.RawMessage)}
+	err = store.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(bucketNodes).ForEach(func(nodeID, raw []byte) error {
+			state.Nodes[string(nodeID)] = append(json.RawMessage{}, raw...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		bucket := tx.Bucket(bucketState)
+		state.Targets = append(json.RawMessage{}, bucket.Get(keyTargets)...)
+		state.Quarantine = append(json.RawMessage{}, bucket.Get(keyQuarantine)...)
+		if raw := bucket.Get(keyVersion); raw != nil {
+			state.Version, err = strconv.Atoi(string(raw))
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	version := state.Version
+	if err = state.Migrate(); err != nil {
+		return err
+	}
+
+	nodes.Lock()
+	state.Restore(nodes)
+	nodes.Unlock()
+
+	if version == runtime.StateVersion || len(state.Nodes) == 0 {
+		return nil
+	}
+	// write all nodes with the current version
+	if err = store.saveAll(nodes); err != nil {
+		return err
+	}
+	log.Printf("[boltstore] migrated %d nodes to state version %d", len(state.Nodes), runtime.StateVersion)
+	return nil
+}
+
+// migrate reads the JSON state and writes all nodes into the database
+func (store *Store) migrate(nodes *runtime.Nodes) error {
+	if err := runtime.LoadJSONState(nodes, store.jsonPath()); err != nil {
+		return err
+	}
+	if err := store.saveAll(nodes); err != nil {
+		return err
+	}
+	log.Printf("[boltstore] migrated the nodes of %s", store.jsonPath())
+	return nil
+}
+
+// saveAll writes all nodes into the database
+func (store *Store) saveAll(nodes *runtime.Nodes) error {
+	nodes.RLock()
+	changed := make([]string, 0, len(nodes.List))
+	for nodeID := range nodes.List {
+		changed = append(changed, nodeID)
+	}
+	nodes.RUnlock()
+	return store.Save(nodes, changed)
+}
+
+// Save writes the changed nodes, removed nodes are deleted.
+// The nodes are serialized under the read lock and written without holding it.
+func (store *Store) Save(nodes *runtime.Nodes, changed []string) error {
+	values := make(map[string][]byte, len(changed))
+	var targets, quarantine []byte
+	var err error
+
+	nodes.RLock()
+	for _, nodeID := range changed {
+		node, ok := nodes.List[nodeID]
+		if !ok {
+			values[nodeID] = nil
+			continue
+		}
+		if values[nodeID], err = json.Marshal(node); err != nil {
+			nodes.RUnlock()
+			return err
+		}
+	}
+	if targets, err = json.Marshal(nodes.Targets); err == nil {
+		quarantine, err = json.Marshal(nodes.Quarantined)
+	}
+	nodes.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketNodes)
+		for nodeID, raw := range values {
+			var err error
+			if raw == nil {
+				err = bucket.Delete([]byte(nodeID))
+			} else {
+				err = bucket.Put([]byte(nodeID), raw)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		state := tx.Bucket(bucketState)
+		if err := state.Put(keyVersion, []byte(strconv.Itoa(runtime.StateVersion))); err != nil {
+			return err
+		}
+		if err := state.Put(keyTargets, targets); err != nil {
+			return err
+		}
+		return state.Put(keyQuarantine, quarantine)
+	})
+}
+
+// Close closes the database
+func (store *Store) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,78 @@
+package boltstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-boltstore")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := &runtime.NodesConfig{StateType: "bolt", StatePath: filepath.Join(dir, "state.db")}
+	nodes := runtime.NewNodes(config)
+	nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "first"}})
+	nodes.Update("f81a67a601eb", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601eb"}})
+	nodes.Close()
+
+	// a second instance loads the nodes
+	nodes = runtime.NewNodes(config)
+	assert.Len(nodes.List, 2)
+	assert.Equal("first", nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+
+	// only the changed nodes are written
+	nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "second"}})
+	nodes.Lock()
+	nodes.List["f81a67a601eb"].Nodeinfo.Hostname = "unchanged"
+	nodes.Unlock()
+	nodes.Close()
+
+	nodes = runtime.NewNodes(config)
+	assert.Len(nodes.List, 2)
+	assert.Equal("second", nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+	assert.Equal("", nodes.List["f81a67a601eb"].Nodeinfo.Hostname)
+	nodes.Close()
+}
+
+func TestMigrate(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-boltstore")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	// the state of the json state store
+	nodes := runtime.NewNodes(&runtime.NodesConfig{StatePath: path})
+	nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "migrated"}})
+	nodes.Close()
+
+	config := &runtime.NodesConfig{StateType: "bolt", StatePath: path}
+	nodes = runtime.NewNodes(config)
+	assert.Len(nodes.List, 1)
+	assert.Equal("migrated", nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+	nodes.Close()
+
+	_, err = os.Stat(path + ".json")
+	assert.NoError(err, "keeps the old state")
+	assert.False(isJSONState(path))
+
+	// the database is not migrated twice
+	os.Remove(path + ".json")
+	nodes = runtime.NewNodes(config)
+	assert.Len(nodes.List, 1)
+	nodes.Close()
+
+	_, err = Open(&runtime.NodesConfig{StatePath: filepath.Join(dir, "missing", "state.db")})
+	assert.Error(err)
+}
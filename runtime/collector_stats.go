@@ -0,0 +1,41 @@
+package runtime
+
+import "time"
+
+// CollectorStats are the self-metrics of the respondd collector,
+// all counters are increasing since the start of the collector
+type CollectorStats struct {
+	Interfaces map[string]*InterfaceStats // indexed by interface name
+
+	DecodeErrors     uint64 // datagrams which could not be deflated or decoded
+	InvalidNodeIDs   uint64 // responses without a valid NodeID
+	NodeIDMismatches uint64 // sections which are dropped for a NodeID different to the response
+	Responses        uint64 // responses stored to the nodes
+	Duplicates       uint64 // further responses of a node in the same round, which are not stored
+	Rejected         uint64 // responses of not allowed sources or unusual addresses (see quarantine of the nodes)
+	MulticastsSent   uint64 // requests to the multicast group or the IPv4 broadcast address of an interface
+	UnicastsSent     uint64
+
+	QueueLength   int    // datagrams waiting for the parser
+	QueueCapacity int    // size of the queue
+	QueueDropped  uint64 // datagrams dropped because of a full queue
+
+	DatabaseQueueLength int    // nodes waiting to be stored in the database
+	DatabaseDropped     uint64 // nodes not stored because of a full database queue
+
+	// responses and their latency in the last complete round
+	RoundResponses  uint64
+	RoundLatencyAvg time.Duration
+	RoundLatencyMax time.Duration
+}
+
+// InterfaceStats are the self-metrics of a collector interface
+type InterfaceStats struct {
+	Received     uint64 // received datagrams
+	RequestsSent uint64 // sent multicast and unicast requests
+
+	Up      bool   // the UDP socket is bound
+	Address string // local address of the UDP socket
+	Error   string // last error of binding or watching the interface
+	Binds   uint64 // successful binds of the UDP socket
+}
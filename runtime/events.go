@@ -0,0 +1,168 @@
+package runtime
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+// EventType is the kind of a transition of a node
+type EventType string
+
+// types of the events
+const (
+	EventNew             EventType = "new"              // a node was seen the first time
+	EventOffline         EventType = "offline"          // a node went offline
+	EventOnline          EventType = "online"           // an offline node is back online
+	EventPruned          EventType = "pruned"           // a node was removed after prune_after
+	EventHostnameChanged EventType = "hostname_changed" // Old and New are the hostnames
+	EventLocationChanged EventType = "location_changed" // Old and New are "latitude,longitude" or empty
+	EventFirmwareChanged EventType = "firmware_changed" // Old and New are the firmware releases
+	EventOwnerChanged    EventType = "owner_changed"    // Old and New are the contacts
+	EventReboot          EventType = "reboot"           // the uptime was reset, Old and New are the uptimes in seconds
+	EventGatewayChanged  EventType = "gateway_changed"  // Old and New are the selected gateways
+)
+
+// eventBufferSize is the count of events queued per subscriber, further events are dropped
+const eventBufferSize = 1024
+
+// Event is a transition of a node
+type Event struct {
+	Type   EventType     `json:"type"`
+	NodeID string        `json:"node_id"`
+	Time   jsontime.Time `json:"time"`
+	Old    string        `json:"old,omitempty"` // previous value of a changed field
+	New    string        `json:"new,omitempty"` // current value of a changed field
+	Node   *Node         `json:"-"`             // copy of the node at the time of the event, without its maps and history
+}
+
+// EventHandler is called for every event of a subscription
+type EventHandler func(event *Event)
+
+type subscriber struct {
+	events chan *Event
+	done   chan interface{}
+}
+
+// Subscribe calls the handler for every event of the nodes in its own goroutine, in order of the events.
+// If the handler is not able to keep up, events are dropped.
+// The returned function unsubscribes and waits for the handler to return,
+// it must not be called while holding the lock of the nodes.
+func (nodes *Nodes) Subscribe(handler EventHandler) (unsubscribe func()) {
+	sub := &subscriber{
+		events: make(chan *Event, eventBufferSize),
+		done:   make(chan interface{}),
+	}
+	go func() {
+		defer close(sub.done)
+		for event := range sub.events {
+			handler(event)
+		}
+	}()
+
+	nodes.subscribersLock.Lock()
+	if nodes.subscribers == nil {
+		nodes.subscribers = make(map[*subscriber]bool)
+	}
+	nodes.subscribers[sub] = true
+	nodes.subscribersLock.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			nodes.subscribersLock.Lock()
+			delete(nodes.subscribers, sub)
+			close(sub.events)
+			nodes.subscribersLock.Unlock()
+			<-sub.done
+		})
+	}
+}
+
+// publish passes the event to all subscribers without blocking
+func (nodes *Nodes) publish(eventType EventType, nodeID string, node *Node, old, new string) {
+	nodes.subscribersLock.RLock()
+	defer nodes.subscribersLock.RUnlock()
+	if len(nodes.subscribers) == 0 {
+		return
+	}
+
+	event := &Event{
+		Type:   eventType,
+		NodeID: nodeID,
+		Time:   jsontime.Now(),
+		Old:    old,
+		New:    new,
+	}
+	if node != nil {
+		copied := *node
+		copied.CustomFields = nil
+		copied.SectionsUpdated = nil
+		copied.History = nil
+		event.Node = &copied
+	}
+	for sub := range nodes.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			log.Printf("dropped event %s of node %s, a subscriber is not able to keep up", eventType, nodeID)
+		}
+	}
+}
+
+// publishChanges publishes the events of the differences between the previous copy of the node (nil for a new node)
+// and the node, the caller has to hold the lock
+func (nodes *Nodes) publishChanges(nodeID string, previous, node *Node) {
+	if previous == nil {
+		nodes.publish(EventNew, nodeID, node, "", "")
+		return
+	}
+	if !previous.Online && node.Online {
+		nodes.publish(EventOnline, nodeID, node, "", "")
+	} else if previous.Online && !node.Online {
+		nodes.publish(EventOffline, nodeID, node, "", "")
+	}
+
+	if old, current := previous.Nodeinfo, node.Nodeinfo; old != nil && current != nil && old != current {
+		if old.Hostname != current.Hostname {
+			nodes.publish(EventHostnameChanged, nodeID, node, old.Hostname, current.Hostname)
+		}
+		if oldLocation, location := formatLocation(old), formatLocation(current); oldLocation != location {
+			nodes.publish(EventLocationChanged, nodeID, node, oldLocation, location)
+		}
+		if old.Software.Firmware.Release != current.Software.Firmware.Release {
+			nodes.publish(EventFirmwareChanged, nodeID, node, old.Software.Firmware.Release, current.Software.Firmware.Release)
+		}
+		if oldOwner, owner := formatOwner(old.Owner), formatOwner(current.Owner); oldOwner != owner {
+			nodes.publish(EventOwnerChanged, nodeID, node, oldOwner, owner)
+		}
+	}
+
+	if old, current := previous.Statistics, node.Statistics; old != nil && current != nil && old != current {
+		if current.Uptime < old.Uptime {
+			nodes.publish(EventReboot, nodeID, node, fmt.Sprintf("%.0f", old.Uptime), fmt.Sprintf("%.0f", current.Uptime))
+		}
+		if old.GatewayIPv4 != current.GatewayIPv4 {
+			nodes.publish(EventGatewayChanged, nodeID, node, old.GatewayIPv4, current.GatewayIPv4)
+		} else if old.GatewayIPv6 != current.GatewayIPv6 {
+			nodes.publish(EventGatewayChanged, nodeID, node, old.GatewayIPv6, current.GatewayIPv6)
+		}
+	}
+}
+
+func formatLocation(nodeinfo *data.NodeInfo) string {
+	if location := nodeinfo.Location; location != nil {
+		return fmt.Sprintf("%f,%f", location.Latitude, location.Longitude)
+	}
+	return ""
+}
+
+func formatOwner(owner *data.Owner) string {
+	if owner != nil {
+		return owner.Contact
+	}
+	return ""
+}
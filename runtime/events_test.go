@@ -0,0 +1,124 @@
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+// collectEvents subscribes to the events of the nodes, the returned function unsubscribes and returns the events
+func collectEvents(nodes *Nodes) func() []*Event {
+	var events []*Event
+	unsubscribe := nodes.Subscribe(func(event *Event) {
+		events = append(events, event)
+	})
+	return func() []*Event {
+		unsubscribe()
+		return events
+	}
+}
+
+func eventTypes(events []*Event) (types []EventType) {
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return
+}
+
+func TestEventsUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := NewNodes(&NodesConfig{})
+	collect := collectEvents(nodes)
+
+	nodes.Update("f81a67a601ea", &data.ResponseData{
+		NodeInfo:   &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "first"},
+		Statistics: &data.Statistics{Uptime: 1000, GatewayIPv4: "gw1"},
+	})
+	// unchanged
+	nodes.Update("f81a67a601ea", &data.ResponseData{
+		NodeInfo:   &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "first"},
+		Statistics: &data.Statistics{Uptime: 1060, GatewayIPv4: "gw1"},
+	})
+	nodes.Update("f81a67a601ea", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{
+			NodeID:   "f81a67a601ea",
+			Hostname: "second",
+			Owner:    &data.Owner{Contact: "owner@example.org"},
+			Location: &data.Location{Latitude: 53.1, Longitude: 8.8},
+		},
+		Statistics: &data.Statistics{Uptime: 10, GatewayIPv4: "gw2"},
+	})
+	nodes.List["f81a67a601ea"].Online = false
+	nodes.Update("f81a67a601ea", &data.ResponseData{})
+
+	events := collect()
+	assert.Equal([]EventType{
+		EventNew,
+		EventHostnameChanged,
+		EventLocationChanged,
+		EventOwnerChanged,
+		EventReboot,
+		EventGatewayChanged,
+		EventOnline,
+	}, eventTypes(events))
+
+	hostname := events[1]
+	assert.Equal("f81a67a601ea", hostname.NodeID)
+	assert.Equal("first", hostname.Old)
+	assert.Equal("second", hostname.New)
+	assert.Equal("second", hostname.Node.Nodeinfo.Hostname)
+	assert.Equal("53.100000,8.800000", events[2].New)
+	assert.Equal("1060", events[4].Old)
+	assert.Equal("gw2", events[5].New)
+}
+
+func TestEventsExpire(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &NodesConfig{}
+	config.OfflineAfter.Duration = time.Minute
+	nodes := NewNodes(config)
+	nodes.Update("offline", &data.ResponseData{})
+	nodes.Update("expire", &data.ResponseData{})
+	nodes.List["offline"].Lastseen = nodes.List["offline"].Lastseen.Add(-time.Hour)
+	nodes.List["expire"].Lastseen = nodes.List["expire"].Lastseen.Add(-time.Hour * 24 * 8)
+
+	collect := collectEvents(nodes)
+	nodes.expire()
+	nodes.expire()
+	nodes.Seen("offline")
+	events := collect()
+
+	assert.Len(events, 3)
+	assert.ElementsMatch([]EventType{EventOffline, EventPruned}, eventTypes(events[:2]))
+	assert.Equal(EventOnline, events[2].Type)
+	assert.Equal("offline", events[2].NodeID)
+}
+
+func TestEventsDropped(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := NewNodes(&NodesConfig{})
+	block := make(chan interface{})
+	count := 0
+	unsubscribe := nodes.Subscribe(func(event *Event) {
+		<-block
+		count++
+	})
+	for i := 0; i < eventBufferSize+10; i++ {
+		nodes.publish(EventNew, "f81a67a601ea", nil, "", "")
+	}
+	close(block)
+	unsubscribe()
+	unsubscribe()
+
+	// the first event is taken by the handler before the buffer is full
+	assert.True(count >= eventBufferSize && count <= eventBufferSize+1)
+
+	// no subscribers
+	nodes.publish(EventNew, "f81a67a601ea", nil, "", "")
+}
@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"encoding/json"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+// FederatedNodes is the state of the nodes shared with other yanic instances
+type FederatedNodes struct {
+	Timestamp jsontime.Time    `json:"timestamp"`
+	Nodes     []*FederatedNode `json:"nodes"`
+}
+
+// FederatedNode is a node including its neighbours, which are not part of the state file
+type FederatedNode struct {
+	*Node
+	Neighbours *data.Neighbours `json:"neighbours,omitempty"`
+}
+
+// NewFederatedNodes returns the shared state of the nodes
+func NewFederatedNodes(nodes *Nodes) *FederatedNodes {
+	federated := &FederatedNodes{
+		Timestamp: jsontime.Now(),
+		Nodes:     make([]*FederatedNode, 0, len(nodes.List)),
+	}
+	for _, node := range nodes.List {
+		if node.Nodeinfo == nil {
+			continue
+		}
+		federated.Nodes = append(federated.Nodes, &FederatedNode{Node: node, Neighbours: node.Neighbours})
+	}
+	return federated
+}
+
+// UnmarshalJSON reads the shared state or a state file of yanic (with the nodes by their NodeID)
+func (federated *FederatedNodes) UnmarshalJSON(raw []byte) error {
+	var document struct {
+		Timestamp jsontime.Time   `json:"timestamp"`
+		Nodes     json.RawMessage `json:"nodes"`
+	}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return err
+	}
+	federated.Timestamp = document.Timestamp
+	federated.Nodes = nil
+	if len(document.Nodes) == 0 || document.Nodes[0] != '{' {
+		return json.Unmarshal(document.Nodes, &federated.Nodes)
+	}
+
+	var byID map[string]*FederatedNode
+	if err := json.Unmarshal(document.Nodes, &byID); err != nil {
+		return err
+	}
+	for _, node := range byID {
+		federated.Nodes = append(federated.Nodes, node)
+	}
+	return nil
+}
+
+// Merge stores the node of another instance, if it was seen later than the known node,
+// and returns the stored node or nil
+func (nodes *Nodes) Merge(federated *FederatedNode) *Node {
+	if federated.Node == nil || federated.Nodeinfo == nil || federated.Nodeinfo.NodeID == "" {
+		return nil
+	}
+	nodeID := federated.Nodeinfo.NodeID
+	node := *federated.Node
+	node.Neighbours = federated.Neighbours
+	node.Address = nil
+	if offlineAfter := nodes.config.offlineAfter(); offlineAfter > 0 && node.Lastseen.Before(jsontime.Now().Add(-offlineAfter)) {
+		node.Online = false
+	}
+
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	known := nodes.List[nodeID]
+	if known != nil {
+		if !node.Lastseen.After(known.Lastseen) {
+			return nil
+		}
+		if known.Firstseen.Before(node.Firstseen) {
+			node.Firstseen = known.Firstseen
+		}
+		if node.History == nil {
+			node.History = known.History
+		}
+	}
+	nodes.applyOverride(nodeID, &node)
+	nodes.readIfaces(node.Nodeinfo)
+	nodes.List[nodeID] = &node
+	nodes.markChanged(nodeID)
+	nodes.publishChanges(nodeID, known, &node)
+	return &node
+}
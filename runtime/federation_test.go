@@ -0,0 +1,96 @@
+package runtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+func TestFederatedNodes(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := NewNodes(&NodesConfig{})
+	nodes.AddNode(&Node{
+		Nodeinfo:   &data.NodeInfo{NodeID: "f81a67a601ea"},
+		Neighbours: &data.Neighbours{NodeID: "f81a67a601ea"},
+	})
+	nodes.List["without-nodeinfo"] = &Node{}
+
+	raw, err := json.Marshal(NewFederatedNodes(nodes))
+	assert.NoError(err)
+
+	var federated FederatedNodes
+	assert.NoError(json.Unmarshal(raw, &federated))
+	assert.Len(federated.Nodes, 1)
+	assert.Equal("f81a67a601ea", federated.Nodes[0].Nodeinfo.NodeID)
+	assert.Equal("f81a67a601ea", federated.Nodes[0].Neighbours.NodeID)
+
+	// state file of yanic
+	raw, err = json.Marshal(nodes)
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal(raw, &federated))
+	assert.Len(federated.Nodes, 2)
+
+	assert.Error(json.Unmarshal([]byte(`{"nodes":"invalid"}`), &federated))
+}
+
+func TestMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &NodesConfig{}
+	config.OfflineAfter.Duration = time.Minute * 10
+	nodes := NewNodes(config)
+	now := jsontime.Now()
+
+	nodes.List["f81a67a601ea"] = &Node{
+		Firstseen: now.Add(-time.Hour),
+		Lastseen:  now.Add(-time.Minute),
+		Nodeinfo:  &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "local"},
+	}
+
+	// older than the known node
+	assert.Nil(nodes.Merge(&FederatedNode{Node: &Node{
+		Lastseen: now.Add(-time.Minute * 2),
+		Nodeinfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "remote"},
+	}}))
+	assert.Equal("local", nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+
+	// newer than the known node
+	merged := nodes.Merge(&FederatedNode{
+		Node: &Node{
+			Firstseen: now,
+			Lastseen:  now,
+			Online:    true,
+			Nodeinfo: &data.NodeInfo{
+				NodeID:   "f81a67a601ea",
+				Hostname: "remote",
+				Network:  data.Network{Mac: "f8:1a:67:a6:01:ea"},
+			},
+		},
+		Neighbours: &data.Neighbours{NodeID: "f81a67a601ea"},
+	})
+	assert.NotNil(merged)
+	assert.Equal("remote", nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+	assert.Equal(now.Add(-time.Hour), merged.Firstseen, "keeps the first seen")
+	assert.True(merged.Online)
+	assert.NotNil(merged.Neighbours)
+	assert.Equal("f81a67a601ea", nodes.GetNodeIDbyAddress("f8:1a:67:a6:01:ea"))
+
+	// unknown node, which is offline already
+	merged = nodes.Merge(&FederatedNode{Node: &Node{
+		Lastseen: now.Add(-time.Hour),
+		Online:   true,
+		Nodeinfo: &data.NodeInfo{NodeID: "f81a67a601eb"},
+	}})
+	assert.NotNil(merged)
+	assert.False(merged.Online)
+
+	// without NodeID
+	assert.Nil(nodes.Merge(&FederatedNode{Node: &Node{Nodeinfo: &data.NodeInfo{}}}))
+	assert.Nil(nodes.Merge(&FederatedNode{}))
+}
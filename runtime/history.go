@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"encoding/json"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+// HistoryEntry is a snapshot of the statistics of a node,
+// the traffic is the counter of the node (bytes since its boot)
+type HistoryEntry struct {
+	Time         jsontime.Time `json:"time"`
+	Clients      uint32        `json:"clients"`
+	LoadAverage  float64       `json:"loadavg"`
+	MemoryUsage  float64       `json:"memory_usage"`
+	RxBytes      float64       `json:"rx_bytes"`
+	TxBytes      float64       `json:"tx_bytes"`
+	ForwardBytes float64       `json:"forward_bytes"`
+	Airtime24    float32       `json:"airtime24,omitempty"` // channel utilization of 2.4 GHz in percent
+	Airtime5     float32       `json:"airtime5,omitempty"`  // channel utilization of 5 GHz in percent
+}
+
+// NewHistoryEntry creates a snapshot of the statistics
+func NewHistoryEntry(statistics *data.Statistics, now jsontime.Time) HistoryEntry {
+	entry := HistoryEntry{
+		Time:        now,
+		Clients:     statistics.Clients.Total,
+		LoadAverage: statistics.LoadAverage,
+	}
+	if memory := statistics.Memory; memory.Total > 0 {
+		entry.MemoryUsage = 1 - float64(memory.Free+memory.Buffers+memory.Cached)/float64(memory.Total)
+	}
+	if traffic := statistics.Traffic.Rx; traffic != nil {
+		entry.RxBytes = traffic.Bytes
+	}
+	if traffic := statistics.Traffic.Tx; traffic != nil {
+		entry.TxBytes = traffic.Bytes
+	}
+	if traffic := statistics.Traffic.Forward; traffic != nil {
+		entry.ForwardBytes = traffic.Bytes
+	}
+	for _, airtime := range statistics.Wireless {
+		if airtime.FrequencyName() == "11g" {
+			entry.Airtime24 = airtime.ChanUtil
+		} else {
+			entry.Airtime5 = airtime.ChanUtil
+		}
+	}
+	return entry
+}
+
+// History is a ring buffer of the last snapshots of the statistics of a node
+type History struct {
+	entries []HistoryEntry
+	next    int // index of the oldest entry, which is replaced next if the buffer is full
+}
+
+// Add appends the entry and drops the oldest entries to keep at most size entries
+func (history *History) Add(entry HistoryEntry, size int) {
+	if len(history.entries) != size && history.next != 0 {
+		// the size was changed, restore the order of the entries
+		history.entries = history.Entries()
+		history.next = 0
+	}
+	if len(history.entries) > size {
+		history.entries = history.entries[len(history.entries)-size:]
+	}
+	if len(history.entries) < size {
+		history.entries = append(history.entries, entry)
+		return
+	}
+	if size == 0 {
+		return
+	}
+	history.entries[history.next] = entry
+	history.next = (history.next + 1) % size
+}
+
+// Len returns the count of entries
+func (history *History) Len() int {
+	return len(history.entries)
+}
+
+// Entries returns a copy of the entries, the oldest first
+func (history *History) Entries() []HistoryEntry {
+	entries := make([]HistoryEntry, 0, len(history.entries))
+	entries = append(entries, history.entries[history.next:]...)
+	return append(entries, history.entries[:history.next]...)
+}
+
+// Last returns the newest entry or nil
+func (history *History) Last() *HistoryEntry {
+	if len(history.entries) == 0 {
+		return nil
+	}
+	last := history.entries[(history.next+len(history.entries)-1)%len(history.entries)]
+	return &last
+}
+
+// MarshalJSON writes the entries as list, the oldest first
+func (history *History) MarshalJSON() ([]byte, error) {
+	return json.Marshal(history.Entries())
+}
+
+// UnmarshalJSON reads a list of entries
+func (history *History) UnmarshalJSON(raw []byte) error {
+	history.next = 0
+	return json.Unmarshal(raw, &history.entries)
+}
+
+// addHistory adds a snapshot of the current statistics of the node,
+// if the last snapshot is older than the history_interval
+func (nodes *Nodes) addHistory(node *Node, now jsontime.Time) {
+	size := nodes.config.historySize()
+	if size <= 0 {
+		node.History = nil
+		return
+	}
+	if node.History == nil {
+		node.History = &History{}
+	}
+	if last := node.History.Last(); last != nil && now.Before(last.Time.Add(nodes.config.HistoryInterval.Duration)) {
+		return
+	}
+	node.History.Add(NewHistoryEntry(node.Statistics, now), size)
+}
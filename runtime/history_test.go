@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+func TestHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	history := &History{}
+	assert.Nil(history.Last())
+	assert.Len(history.Entries(), 0)
+
+	for clients := uint32(1); clients <= 5; clients++ {
+		history.Add(HistoryEntry{Clients: clients}, 3)
+	}
+	assert.Equal(3, history.Len())
+	assert.Equal(uint32(5), history.Last().Clients)
+	assert.Equal([]HistoryEntry{{Clients: 3}, {Clients: 4}, {Clients: 5}}, history.Entries())
+
+	// the size is reduced
+	history.Add(HistoryEntry{Clients: 6}, 2)
+	assert.Equal([]HistoryEntry{{Clients: 5}, {Clients: 6}}, history.Entries())
+
+	// the size is increased
+	history.Add(HistoryEntry{Clients: 7}, 4)
+	assert.Equal([]HistoryEntry{{Clients: 5}, {Clients: 6}, {Clients: 7}}, history.Entries())
+
+	raw, err := json.Marshal(history)
+	assert.NoError(err)
+	restored := &History{}
+	assert.NoError(json.Unmarshal(raw, restored))
+	assert.Equal(3, restored.Len())
+	assert.Equal(uint32(7), restored.Last().Clients)
+}
+
+func TestNewHistoryEntry(t *testing.T) {
+	assert := assert.New(t)
+
+	statistics := &data.Statistics{
+		Clients:     data.Clients{Total: 12},
+		LoadAverage: 0.5,
+		Memory:      data.Memory{Total: 100, Free: 20, Buffers: 10, Cached: 20},
+		Wireless: data.WirelessStatistics{
+			{Frequency: 2412, ChanUtil: 30},
+			{Frequency: 5220, ChanUtil: 5},
+		},
+	}
+	statistics.Traffic.Rx = &data.Traffic{Bytes: 1000}
+	statistics.Traffic.Tx = &data.Traffic{Bytes: 2000}
+
+	entry := NewHistoryEntry(statistics, jsontime.Now())
+	assert.Equal(uint32(12), entry.Clients)
+	assert.Equal(0.5, entry.LoadAverage)
+	assert.Equal(0.5, entry.MemoryUsage)
+	assert.Equal(1000.0, entry.RxBytes)
+	assert.Equal(2000.0, entry.TxBytes)
+	assert.Equal(0.0, entry.ForwardBytes)
+	assert.Equal(float32(30), entry.Airtime24)
+	assert.Equal(float32(5), entry.Airtime5)
+}
+
+func TestNodesHistory(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &NodesConfig{HistorySize: 3}
+	config.HistoryInterval.Duration = time.Hour
+	nodes := NewNodes(config)
+
+	statistics := func(clients uint32) *data.ResponseData {
+		return &data.ResponseData{Statistics: &data.Statistics{Clients: data.Clients{Total: clients}}}
+	}
+	node := nodes.Update("f81a67a601ea", statistics(1))
+	nodes.Update("f81a67a601ea", statistics(2))
+	nodes.Update("f81a67a601ea", &data.ResponseData{})
+	assert.Equal(1, node.History.Len(), "within the history_interval")
+
+	node.History.entries[0].Time = node.History.entries[0].Time.Add(-time.Hour)
+	nodes.Update("f81a67a601ea", statistics(3))
+	assert.Equal(2, node.History.Len())
+	assert.Equal(uint32(3), node.History.Last().Clients)
+
+	// the history is kept in the state
+	raw, err := json.Marshal(node)
+	assert.NoError(err)
+	restored := &Node{}
+	assert.NoError(json.Unmarshal(raw, restored))
+	assert.Equal(2, restored.History.Len())
+
+	// disabled
+	config.HistorySize = 0
+	nodes.Update("f81a67a601ea", statistics(4))
+	assert.Nil(node.History)
+}
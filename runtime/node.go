@@ -1,7 +1,10 @@
 package runtime
 
 import (
+	"encoding/json"
 	"net"
+	"sort"
+	"time"
 
 	"github.com/FreifunkBremen/yanic/data"
 	"github.com/FreifunkBremen/yanic/lib/jsontime"
@@ -9,13 +12,21 @@ import (
 
 // Node struct
 type Node struct {
-	Address    *net.UDPAddr     `json:"-"` // the last known address
-	Firstseen  jsontime.Time    `json:"firstseen"`
-	Lastseen   jsontime.Time    `json:"lastseen"`
-	Online     bool             `json:"online"`
-	Statistics *data.Statistics `json:"statistics"`
-	Nodeinfo   *data.NodeInfo   `json:"nodeinfo"`
-	Neighbours *data.Neighbours `json:"-"`
+	Address      *net.UDPAddr               `json:"-"` // the last known address
+	Firstseen    jsontime.Time              `json:"firstseen"`
+	Lastseen     jsontime.Time              `json:"lastseen"`
+	Online       bool                       `json:"online"`
+	Statistics   *data.Statistics           `json:"statistics"`
+	Nodeinfo     *data.NodeInfo             `json:"nodeinfo"`
+	Neighbours   *data.Neighbours           `json:"-"`
+	CustomFields map[string]json.RawMessage `json:"custom_fields,omitempty"` // unknown respondd sections and fields
+
+	SectionsUpdated map[string]jsontime.Time `json:"sections_updated,omitempty"`  // last update of each respondd section
+	StaleSections   []string                 `json:"stale_sections,omitempty"`    // sections which are missing in the recent responses
+	Source          string                   `json:"source,omitempty"`            // label of the input of the last update (e.g. respondd)
+	History         *History                 `json:"history,omitempty"`           // last snapshots of the statistics (history_size)
+	Override        *Override                `json:"override,omitempty"`          // active administrative override (overrides_path)
+	Reported        *data.NodeInfo           `json:"reported_nodeinfo,omitempty"` // nodeinfo as reported by the node, while an override is active
 }
 
 // Link represents a link between two nodes
@@ -34,3 +45,76 @@ func (node *Node) IsGateway() bool {
 	}
 	return false
 }
+
+// Tags returns the custom tags of the active override
+func (node *Node) Tags() map[string]string {
+	if override := node.Override; override != nil {
+		return override.Tags
+	}
+	return nil
+}
+
+// staleSections returns the sections which were not updated
+// within staleAfter before the last response of the node
+func (node *Node) staleSections(staleAfter time.Duration) []string {
+	if staleAfter <= 0 {
+		return nil
+	}
+	updatedAfter := node.Lastseen.Add(-staleAfter)
+
+	var stale []string
+	for section, updated := range node.SectionsUpdated {
+		if updated.Before(updatedAfter) {
+			stale = append(stale, section)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// mergeSections replaces the sections of the node which are part of the response
+// and keeps the last known copy of the missing ones
+func (node *Node) mergeSections(res *data.ResponseData, now jsontime.Time) {
+	if node.SectionsUpdated == nil {
+		node.SectionsUpdated = make(map[string]jsontime.Time)
+	}
+	updated := func(section string) {
+		node.SectionsUpdated[section] = now
+		if raw, ok := res.CustomFields[section]; ok {
+			node.setCustomField(section, raw)
+		} else {
+			delete(node.CustomFields, section)
+		}
+	}
+
+	if res.NodeInfo != nil {
+		node.Nodeinfo = res.NodeInfo
+		node.Reported = nil
+		updated(data.SectionNodeInfo)
+	}
+	if res.Statistics != nil {
+		node.Statistics = res.Statistics
+		updated(data.SectionStatistics)
+	}
+	if res.Neighbours != nil {
+		node.Neighbours = res.Neighbours
+		updated(data.SectionNeighbours)
+	}
+	for section, raw := range res.CustomFields {
+		if section == data.SectionNodeInfo || section == data.SectionStatistics || section == data.SectionNeighbours {
+			continue
+		}
+		node.SectionsUpdated[section] = now
+		node.setCustomField(section, raw)
+	}
+	if len(node.CustomFields) == 0 {
+		node.CustomFields = nil
+	}
+}
+
+func (node *Node) setCustomField(section string, raw json.RawMessage) {
+	if node.CustomFields == nil {
+		node.CustomFields = make(map[string]json.RawMessage)
+	}
+	node.CustomFields[section] = raw
+}
@@ -1,9 +1,12 @@
 package runtime
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,3 +25,42 @@ func TestNode(t *testing.T) {
 	node.Nodeinfo.VPN = false
 	assert.False(node.IsGateway())
 }
+
+func TestNodeMergeSections(t *testing.T) {
+	assert := assert.New(t)
+
+	first := jsontime.Now().Add(-time.Hour)
+	node := &Node{}
+	node.mergeSections(&data.ResponseData{
+		NodeInfo:   &data.NodeInfo{Hostname: "blub"},
+		Statistics: &data.Statistics{},
+		CustomFields: map[string]json.RawMessage{
+			"nodeinfo": json.RawMessage(`{"extra":1}`),
+			"wireless": json.RawMessage(`{}`),
+		},
+	}, first)
+
+	now := jsontime.Now()
+	node.mergeSections(&data.ResponseData{
+		Statistics: &data.Statistics{NodeID: "new"},
+	}, now)
+
+	// the missing sections are kept
+	assert.Equal("blub", node.Nodeinfo.Hostname)
+	assert.Equal("new", node.Statistics.NodeID)
+	assert.Contains(node.CustomFields, "nodeinfo")
+	assert.Contains(node.CustomFields, "wireless")
+	assert.Equal(first, node.SectionsUpdated[data.SectionNodeInfo])
+	assert.Equal(first, node.SectionsUpdated["wireless"])
+	assert.Equal(now, node.SectionsUpdated[data.SectionStatistics])
+
+	node.Lastseen = now
+	assert.Nil(node.staleSections(0))
+	assert.Nil(node.staleSections(2 * time.Hour))
+	assert.Equal([]string{"nodeinfo", "wireless"}, node.staleSections(time.Minute))
+
+	// unknown fields of an updated section are replaced
+	node.mergeSections(&data.ResponseData{NodeInfo: &data.NodeInfo{}}, now)
+	assert.NotContains(node.CustomFields, "nodeinfo")
+	assert.Equal([]string{"wireless"}, node.staleSections(time.Minute))
+}
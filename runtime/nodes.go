@@ -2,6 +2,8 @@ package runtime
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -13,32 +15,90 @@ import (
 
 // Nodes struct: cache DB of Node's structs
 type Nodes struct {
-	List          map[string]*Node  `json:"nodes"` // the current nodemap, indexed by node ID
-	ifaceToNodeID map[string]string // mapping from MAC address to NodeID
-	config        *NodesConfig
+	List            map[string]*Node        `json:"nodes"`                // the current nodemap, indexed by node ID
+	Targets         map[string]*TargetState `json:"targets,omitempty"`    // reachability of the static respondd targets
+	Quarantined     []*QuarantineEntry      `json:"quarantine,omitempty"` // latest rejected responses
+	ifaceToNodeID   map[string]string       // mapping from MAC address to NodeID
+	config          *NodesConfig
+	store           StateStore
+	changed         map[string]bool // IDs of the nodes changed since the last save
+	stop            chan interface{}
+	done            chan interface{}
+	subscribers     map[*subscriber]bool // subscribers of the events
+	subscribersLock sync.RWMutex
+
+	overrides        map[string]*Override // administrative overrides by node ID
+	overridesPath    string
+	overridesModTime time.Time
 	sync.RWMutex
 }
 
-// NewNodes create Nodes structs
+// NewNodes create Nodes structs, errors of the state store and the overrides are logged
 func NewNodes(config *NodesConfig) *Nodes {
+	nodes, err := LoadNodes(config)
+	if err != nil {
+		log.Println(err)
+	}
+	if err := nodes.LoadOverrides(config.OverridesPath); err != nil {
+		log.Println(err)
+	}
+	return nodes
+}
+
+// LoadNodes create Nodes structs and loads the nodes of the state store.
+// On an error the nodes are returned as well (without the stored nodes).
+func LoadNodes(config *NodesConfig) (*Nodes, error) {
 	nodes := &Nodes{
 		List:          make(map[string]*Node),
+		Targets:       make(map[string]*TargetState),
 		ifaceToNodeID: make(map[string]string),
 		config:        config,
+		changed:       make(map[string]bool),
 	}
 
-	if config.StatePath != "" {
-		nodes.load()
+	store, err := openStateStore(config)
+	if err != nil {
+		return nodes, fmt.Errorf("failed to open the state store: %s", err)
 	}
+	nodes.store = store
 
-	return nodes
+	if config.StatePath != "" {
+		return nodes, nodes.load()
+	}
+	return nodes, nil
 }
 
 // Start all services to manage Nodes
 func (nodes *Nodes) Start() {
+	nodes.stop = make(chan interface{})
+	nodes.done = make(chan interface{})
 	go nodes.worker()
 }
 
+// Close stops the services, saves the nodes a last time and closes the state store
+func (nodes *Nodes) Close() {
+	if nodes.stop != nil {
+		close(nodes.stop)
+		<-nodes.done
+		nodes.stop = nil
+	}
+	nodes.save()
+	if nodes.store != nil {
+		if err := nodes.store.Close(); err != nil {
+			log.Println("failed to close the state store:", err)
+		}
+		nodes.store = nil
+	}
+}
+
+// markChanged marks a node to be saved, the caller has to hold the lock
+func (nodes *Nodes) markChanged(nodeID string) {
+	if nodes.changed == nil {
+		nodes.changed = make(map[string]bool)
+	}
+	nodes.changed[nodeID] = true
+}
+
 func (nodes *Nodes) AddNode(node *Node) {
 	nodeinfo := node.Nodeinfo
 	if nodeinfo == nil || nodeinfo.NodeID == "" {
@@ -48,6 +108,12 @@ func (nodes *Nodes) AddNode(node *Node) {
 	defer nodes.Unlock()
 	nodes.List[nodeinfo.NodeID] = node
 	nodes.readIfaces(nodeinfo)
+	nodes.markChanged(nodeinfo.NodeID)
+}
+
+// OfflineAfter returns the period after which a node is marked as offline
+func (nodes *Nodes) OfflineAfter() time.Duration {
+	return nodes.config.offlineAfter()
 }
 
 // Update a Node
@@ -55,18 +121,23 @@ func (nodes *Nodes) Update(nodeID string, res *data.ResponseData) *Node {
 	now := jsontime.Now()
 
 	nodes.Lock()
+	defer nodes.Unlock()
+
 	node, _ := nodes.List[nodeID]
 
+	var previous *Node
 	if node == nil {
 		node = &Node{
 			Firstseen: now,
 		}
 		nodes.List[nodeID] = node
+	} else {
+		copied := *node
+		previous = &copied
 	}
 	if res.NodeInfo != nil {
 		nodes.readIfaces(res.NodeInfo)
 	}
-	nodes.Unlock()
 
 	// Update wireless statistics
 	if statistics := res.Statistics; statistics != nil {
@@ -79,13 +150,38 @@ func (nodes *Nodes) Update(nodeID string, res *data.ResponseData) *Node {
 	// Update fields
 	node.Lastseen = now
 	node.Online = true
-	node.Neighbours = res.Neighbours
-	node.Nodeinfo = res.NodeInfo
-	node.Statistics = res.Statistics
+	node.mergeSections(res, now)
+	nodes.applyOverride(nodeID, node)
+	node.StaleSections = node.staleSections(nodes.config.sectionStaleAfter())
+	if res.Statistics != nil {
+		nodes.addHistory(node, now)
+	}
+	nodes.markChanged(nodeID)
+	nodes.publishChanges(nodeID, previous, node)
 
 	return node
 }
 
+// Seen marks a known node as online without changing its data,
+// e.g. for a duplicate response
+func (nodes *Nodes) Seen(nodeID string) {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	node := nodes.List[nodeID]
+	if node == nil {
+		return
+	}
+	wasOnline := node.Online
+	node.Lastseen = jsontime.Now()
+	node.Online = true
+	node.StaleSections = node.staleSections(nodes.config.sectionStaleAfter())
+	nodes.markChanged(nodeID)
+	if !wasOnline {
+		nodes.publish(EventOnline, nodeID, node, "", "")
+	}
+}
+
 // Select selects a list of nodes to be returned
 func (nodes *Nodes) Select(f func(*Node) bool) []*Node {
 	nodes.RLock()
@@ -141,13 +237,21 @@ func (nodes *Nodes) NodeLinks(node *Node) (result []Link) {
 	return result
 }
 
-// Periodically saves the cached DB to json file
+// Periodically saves the cached DB to the state store
 func (nodes *Nodes) worker() {
-	c := time.Tick(nodes.config.SaveInterval.Duration)
-
-	for range c {
-		nodes.expire()
-		nodes.save()
+	defer close(nodes.done)
+	ticker := time.NewTicker(nodes.config.SaveInterval.Duration)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-nodes.stop:
+			return
+		case <-ticker.C:
+			nodes.reloadOverrides()
+			nodes.expire()
+			nodes.save()
+		}
 	}
 }
 
@@ -173,9 +277,13 @@ func (nodes *Nodes) expire() {
 		if node.Lastseen.Before(pruneAfter) {
 			// expire
 			delete(nodes.List, id)
-		} else if node.Lastseen.Before(offlineAfter) {
+			nodes.markChanged(id)
+			nodes.publish(EventPruned, id, node, "", "")
+		} else if node.Lastseen.Before(offlineAfter) && node.Online {
 			// set to offline
 			node.Online = false
+			nodes.markChanged(id)
+			nodes.publish(EventOffline, id, node, "", "")
 		}
 	}
 }
@@ -209,36 +317,66 @@ func (nodes *Nodes) readIfaces(nodeinfo *data.NodeInfo) {
 	}
 }
 
-func (nodes *Nodes) load() {
-	path := nodes.config.StatePath
+func (nodes *Nodes) load() error {
+	if nodes.store == nil {
+		return nil
+	}
+	if err := nodes.store.Load(nodes); err != nil {
+		return fmt.Errorf("failed to load cached nodes: %s", err)
+	}
+	log.Println("loaded", len(nodes.List), "nodes")
 
-	if f, err := os.Open(path); err == nil { // transform data to legacy meshviewer
-		if err = json.NewDecoder(f).Decode(nodes); err == nil {
-			log.Println("loaded", len(nodes.List), "nodes")
+	nodes.Lock()
+	for _, node := range nodes.List {
+		if node.Nodeinfo != nil {
+			nodes.readIfaces(node.Nodeinfo)
+		}
+	}
+	nodes.Unlock()
+	return nil
+}
 
-			nodes.Lock()
-			for _, node := range nodes.List {
-				if node.Nodeinfo != nil {
-					nodes.readIfaces(node.Nodeinfo)
-				}
-			}
-			nodes.Unlock()
+// Rewrite writes all nodes into the state store with the current state version
+func (nodes *Nodes) Rewrite() error {
+	if nodes.store == nil {
+		return errors.New("no state store opened")
+	}
 
-		} else {
-			log.Println("failed to unmarshal nodes:", err)
-		}
-	} else {
-		log.Println("failed to load cached nodes:", err)
+	nodes.Lock()
+	changed := make([]string, 0, len(nodes.List))
+	for nodeID := range nodes.List {
+		changed = append(changed, nodeID)
 	}
+	nodes.changed = make(map[string]bool)
+	nodes.Unlock()
+
+	return nodes.store.Save(nodes, changed)
 }
 
+// save stores the nodes, which changed since the last save
 func (nodes *Nodes) save() {
-	// Locking foo
-	nodes.RLock()
-	defer nodes.RUnlock()
+	if nodes.store == nil {
+		return
+	}
+
+	nodes.Lock()
+	changed := make([]string, 0, len(nodes.changed))
+	for nodeID := range nodes.changed {
+		changed = append(changed, nodeID)
+	}
+	nodes.changed = make(map[string]bool)
+	nodes.Unlock()
+
+	if err := nodes.store.Save(nodes, changed); err != nil {
+		log.Println("failed to save nodes:", err)
 
-	// serialize nodes
-	SaveJSON(nodes, nodes.config.StatePath)
+		// retry on the next save
+		nodes.Lock()
+		for _, nodeID := range changed {
+			nodes.markChanged(nodeID)
+		}
+		nodes.Unlock()
+	}
 }
 
 // SaveJSON to path
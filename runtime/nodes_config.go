@@ -1,11 +1,59 @@
 package runtime
 
-import "github.com/FreifunkBremen/yanic/lib/duration"
+import (
+	"time"
+
+	"github.com/FreifunkBremen/yanic/lib/duration"
+)
+
+// stateTypeDefault is the type of the state store, if none is configured
+const stateTypeDefault = "json"
 
 type NodesConfig struct {
-	StatePath    string            `toml:"state_path"`
-	SaveInterval duration.Duration `toml:"save_interval"` // Save nodes periodically
-	OfflineAfter duration.Duration `toml:"offline_after"` // Set node to offline if not seen within this period
-	PruneAfter   duration.Duration `toml:"prune_after"`   // Remove nodes after n days of inactivity
-	Output       map[string]interface{}
+	StateType         string            `toml:"state_type"` // Type of the state store (e.g. json or bolt)
+	StatePath         string            `toml:"state_path"`
+	StateCompression  string            `toml:"state_compression"`   // Compression of the json state file (gzip or zstd)
+	SaveInterval      duration.Duration `toml:"save_interval"`       // Save nodes periodically
+	OfflineAfter      duration.Duration `toml:"offline_after"`       // Set node to offline if not seen within this period
+	PruneAfter        duration.Duration `toml:"prune_after"`         // Remove nodes after n days of inactivity
+	SectionStaleAfter duration.Duration `toml:"section_stale_after"` // Mark a section as stale if not updated within this period (default: offline_after)
+	HistorySize       int               `toml:"history_size"`        // Count of statistics snapshots kept per node (default: 0, disabled)
+	HistoryInterval   duration.Duration `toml:"history_interval"`    // Minimal period between two snapshots
+	OverridesPath     string            `toml:"overrides_path"`      // File with administrative overrides of the nodes (reloaded on changes)
+	Output            map[string]interface{}
+}
+
+// stateType returns the configured type of the state store
+func (config *NodesConfig) stateType() string {
+	if config.StateType == "" {
+		return stateTypeDefault
+	}
+	return config.StateType
+}
+
+// offlineAfter returns the period after which a node is marked as offline
+func (config *NodesConfig) offlineAfter() time.Duration {
+	if config == nil {
+		return 0
+	}
+	return config.OfflineAfter.Duration
+}
+
+// historySize returns the count of statistics snapshots kept per node
+func (config *NodesConfig) historySize() int {
+	if config == nil {
+		return 0
+	}
+	return config.HistorySize
+}
+
+// sectionStaleAfter returns the period after which a section is marked as stale
+func (config *NodesConfig) sectionStaleAfter() time.Duration {
+	if config == nil {
+		return 0
+	}
+	if config.SectionStaleAfter.Duration > 0 {
+		return config.SectionStaleAfter.Duration
+	}
+	return config.OfflineAfter.Duration
 }
@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -55,9 +56,16 @@ func TestLoadAndSave(t *testing.T) {
 	// not autoload without StatePath
 	NewNodes(config)
 
-	// Test unmarshalable /dev/null - autolead with StatePath
-	config.StatePath = "/dev/null"
+	// Test unmarshalable file - autolead with StatePath
+	tmpfile, _ := ioutil.TempFile("/tmp", "nodes")
+	tmpfile.WriteString("unmarshalable")
+	tmpfile.Close()
+	config.StatePath = tmpfile.Name()
 	nodes := NewNodes(config)
+	_, err := os.Stat(tmpfile.Name() + ".broken")
+	assert.NoError(err)
+	os.Remove(tmpfile.Name() + ".broken")
+
 	// Test unopen able
 	config.StatePath = "/root/nodes.json"
 	nodes.load()
@@ -65,13 +73,13 @@ func TestLoadAndSave(t *testing.T) {
 	config.StatePath = "testdata/nodes.json"
 	nodes.load()
 
-	tmpfile, _ := ioutil.TempFile("/tmp", "nodes")
+	tmpfile, _ = ioutil.TempFile("/tmp", "nodes")
 	config.StatePath = tmpfile.Name()
 	nodes.save()
 	os.Remove(tmpfile.Name())
 
-	assert.PanicsWithValue("open /dev/null.tmp: permission denied", func() {
-		SaveJSON(nodes, "/dev/null")
+	assert.PanicsWithValue("open /nonexistent/nodes.json.tmp: no such file or directory", func() {
+		SaveJSON(nodes, "/nonexistent/nodes.json")
 	})
 
 	tmpfile, _ = ioutil.TempFile("/tmp", "nodes")
@@ -101,6 +109,9 @@ func TestUpdateNodes(t *testing.T) {
 			},
 		},
 		NodeInfo: &data.NodeInfo{},
+		CustomFields: map[string]json.RawMessage{
+			"wireless": json.RawMessage(`{}`),
+		},
 	}
 	nodes.Update("abcdef012345", res)
 
@@ -108,6 +119,61 @@ func TestUpdateNodes(t *testing.T) {
 	nodes.Update("abcdef012345", res)
 
 	assert.Len(nodes.List, 1)
+	assert.Contains(nodes.List["abcdef012345"].CustomFields, "wireless")
+}
+
+func TestUpdateNodesStaleSections(t *testing.T) {
+	assert := assert.New(t)
+	config := &NodesConfig{}
+	config.OfflineAfter.Duration = time.Minute * 10
+	nodes := &Nodes{
+		config:        config,
+		List:          make(map[string]*Node),
+		ifaceToNodeID: make(map[string]string),
+	}
+
+	node := nodes.Update("abcdef012345", &data.ResponseData{
+		NodeInfo:   &data.NodeInfo{NodeID: "abcdef012345", Hostname: "blub"},
+		Statistics: &data.Statistics{},
+	})
+	assert.Empty(node.StaleSections)
+	node.SectionsUpdated[data.SectionNodeInfo] = node.Lastseen.Add(-time.Hour)
+
+	// partial response keeps nodeinfo, but marks it as stale
+	node = nodes.Update("abcdef012345", &data.ResponseData{
+		Statistics: &data.Statistics{},
+	})
+	assert.Equal("blub", node.Nodeinfo.Hostname)
+	assert.Equal([]string{data.SectionNodeInfo}, node.StaleSections)
+
+	// section_stale_after overrides offline_after
+	config.SectionStaleAfter.Duration = time.Hour * 2
+	node = nodes.Update("abcdef012345", &data.ResponseData{})
+	assert.Empty(node.StaleSections)
+}
+
+func TestSeenNodes(t *testing.T) {
+	assert := assert.New(t)
+	nodes := &Nodes{
+		config:        &NodesConfig{},
+		List:          make(map[string]*Node),
+		ifaceToNodeID: make(map[string]string),
+	}
+
+	// unknown nodes are not added
+	nodes.Seen("abcdef012345")
+	assert.Len(nodes.List, 0)
+
+	node := nodes.Update("abcdef012345", &data.ResponseData{
+		NodeInfo: &data.NodeInfo{NodeID: "abcdef012345", Hostname: "blub"},
+	})
+	node.Online = false
+	node.Lastseen = jsontime.Now().Add(-time.Hour)
+
+	nodes.Seen("abcdef012345")
+	assert.True(node.Online)
+	assert.True(node.Lastseen.After(jsontime.Now().Add(-time.Minute)))
+	assert.Equal("blub", node.Nodeinfo.Hostname)
 }
 
 func TestSelectNodes(t *testing.T) {
@@ -0,0 +1,137 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/naoina/toml"
+)
+
+// Override replaces or adds values of the nodeinfo reported by a node
+type Override struct {
+	Hostname   string            `toml:"hostname" json:"hostname,omitempty"`
+	Location   *data.Location    `toml:"location" json:"location,omitempty"`
+	Owner      string            `toml:"owner" json:"owner,omitempty"`             // contact of the owner
+	SiteCode   string            `toml:"site_code" json:"site_code,omitempty"`     // site code of the system
+	DomainCode string            `toml:"domain_code" json:"domain_code,omitempty"` // domain code of the system
+	Tags       map[string]string `toml:"tags" json:"tags,omitempty"`               // custom tags, e.g. for the outputs and databases
+}
+
+// ReadOverrides reads the overrides by NodeID of a TOML file
+func ReadOverrides(path string) (map[string]*Override, error) {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var overrides map[string]*Override
+	if err := toml.Unmarshal(file, &overrides); err != nil {
+		return nil, err
+	}
+	for nodeID, override := range overrides {
+		if nodeID == "" {
+			return nil, errors.New("override without a NodeID")
+		}
+		if override == nil {
+			delete(overrides, nodeID)
+		}
+	}
+	return overrides, nil
+}
+
+// LoadOverrides reads the overrides file and applies it to all nodes.
+// An empty path removes the overrides, on an error the running ones are kept.
+func (nodes *Nodes) LoadOverrides(path string) error {
+	var overrides map[string]*Override
+	var modTime time.Time
+	if path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to read the overrides: %s", err)
+		}
+		overrides, err = ReadOverrides(path)
+		if err != nil {
+			return fmt.Errorf("failed to read the overrides: %s", err)
+		}
+		modTime = info.ModTime()
+	}
+
+	nodes.Lock()
+	defer nodes.Unlock()
+	nodes.overrides = overrides
+	nodes.overridesPath = path
+	nodes.overridesModTime = modTime
+	for nodeID, node := range nodes.List {
+		nodes.applyOverride(nodeID, node)
+	}
+	if path != "" {
+		log.Println("loaded", len(overrides), "overrides")
+	}
+	return nil
+}
+
+// reloadOverrides reads the overrides file again, if it was modified since the last load
+func (nodes *Nodes) reloadOverrides() {
+	nodes.RLock()
+	path, modTime := nodes.overridesPath, nodes.overridesModTime
+	nodes.RUnlock()
+	if path == "" {
+		return
+	}
+	if info, err := os.Stat(path); err == nil && info.ModTime().Equal(modTime) {
+		return
+	}
+	if err := nodes.LoadOverrides(path); err != nil {
+		log.Println(err)
+	}
+}
+
+// applyOverride derives the nodeinfo of a node from the reported one and its override
+// and records the active override in the node, the caller has to hold the lock.
+// The reported nodeinfo is kept in the node, so a removed override is reverted immediately.
+func (nodes *Nodes) applyOverride(nodeID string, node *Node) {
+	reported := node.Nodeinfo
+	if node.Reported != nil {
+		reported = node.Reported
+	}
+	override := nodes.overrides[nodeID]
+	if override == nil {
+		if node.Override != nil || node.Reported != nil {
+			node.Override = nil
+			node.Nodeinfo = reported
+			node.Reported = nil
+			nodes.markChanged(nodeID)
+		}
+		return
+	}
+	node.Override = override
+	nodes.markChanged(nodeID)
+	if reported == nil {
+		return
+	}
+
+	// copy the nodeinfo, the reported one is kept
+	nodeinfo := *reported
+	if override.Hostname != "" {
+		nodeinfo.Hostname = override.Hostname
+	}
+	if override.Location != nil {
+		location := *override.Location
+		nodeinfo.Location = &location
+	}
+	if override.Owner != "" {
+		nodeinfo.Owner = &data.Owner{Contact: override.Owner}
+	}
+	if override.SiteCode != "" {
+		nodeinfo.System.SiteCode = override.SiteCode
+	}
+	if override.DomainCode != "" {
+		nodeinfo.System.DomainCode = override.DomainCode
+	}
+	node.Nodeinfo = &nodeinfo
+	node.Reported = reported
+}
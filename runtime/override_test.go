@@ -0,0 +1,143 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+func TestReadOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	overrides, err := ReadOverrides("testdata/overrides.toml")
+	assert.NoError(err)
+	assert.Len(overrides, 2)
+
+	override := overrides["f81a67a601ea"]
+	assert.Equal("community-center", override.Hostname)
+	assert.Equal("admin@example.org", override.Owner)
+	assert.Equal("ffhb", override.SiteCode)
+	assert.Equal("city", override.DomainCode)
+	assert.Equal(&data.Location{Latitude: 53.07, Longitude: 8.81}, override.Location)
+	assert.Equal(map[string]string{"room": "attic"}, override.Tags)
+
+	override = overrides["f81a67a601eb"]
+	assert.Equal("", override.Hostname)
+	assert.Nil(override.Location)
+	assert.Equal(map[string]string{"sponsor": "library"}, override.Tags)
+
+	_, err = ReadOverrides("testdata/missing.toml")
+	assert.Error(err)
+}
+
+func TestApplyOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	nodes := NewNodes(&NodesConfig{OverridesPath: "testdata/overrides.toml"})
+
+	nodeinfo := &data.NodeInfo{
+		NodeID:   "f81a67a601ea",
+		Hostname: "reported",
+		Owner:    &data.Owner{Contact: "reported@example.org"},
+		System:   data.System{SiteCode: "other"},
+	}
+	node := nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: nodeinfo})
+	assert.Equal("community-center", node.Nodeinfo.Hostname)
+	assert.Equal("admin@example.org", node.Nodeinfo.Owner.Contact)
+	assert.Equal("ffhb", node.Nodeinfo.System.SiteCode)
+	assert.Equal("city", node.Nodeinfo.System.DomainCode)
+	assert.Equal(53.07, node.Nodeinfo.Location.Latitude)
+	assert.Equal(map[string]string{"room": "attic"}, node.Tags())
+	assert.NotNil(node.Override)
+
+	// the response is not changed and kept as reported nodeinfo
+	assert.Equal("reported", nodeinfo.Hostname)
+	assert.Equal("reported@example.org", nodeinfo.Owner.Contact)
+	assert.Equal(nodeinfo, node.Reported)
+
+	// the override is applied again to the reported nodeinfo
+	node = nodes.Update("f81a67a601ea", &data.ResponseData{})
+	assert.Equal("community-center", node.Nodeinfo.Hostname)
+	assert.Equal(nodeinfo, node.Reported)
+
+	// only the tags are added
+	node = nodes.Update("f81a67a601eb", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601eb", Hostname: "reported"}})
+	assert.Equal("reported", node.Nodeinfo.Hostname)
+	assert.Nil(node.Nodeinfo.Location)
+	assert.Equal(map[string]string{"sponsor": "library"}, node.Tags())
+
+	// without an override
+	node = nodes.Update("f81a67a601ec", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ec", Hostname: "reported"}})
+	assert.Equal("reported", node.Nodeinfo.Hostname)
+	assert.Nil(node.Override)
+	assert.Nil(node.Reported)
+	assert.Nil(node.Tags())
+}
+
+func TestReloadOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-overrides")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "overrides.toml")
+
+	writeOverrides := func(content string, modTime time.Time) {
+		assert.NoError(ioutil.WriteFile(path, []byte(content), 0644))
+		assert.NoError(os.Chtimes(path, modTime, modTime))
+	}
+	modTime := time.Now().Add(-time.Hour)
+	writeOverrides("[f81a67a601ea]\nhostname = \"first\"\n", modTime)
+
+	nodes := NewNodes(&NodesConfig{OverridesPath: path})
+	node := nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "reported"}})
+	assert.Equal("first", node.Nodeinfo.Hostname)
+
+	// unchanged file
+	nodes.reloadOverrides()
+	assert.Equal("first", node.Nodeinfo.Hostname)
+
+	// changed file
+	writeOverrides("[f81a67a601ea]\nhostname = \"second\"\n", modTime.Add(time.Minute))
+	nodes.reloadOverrides()
+	assert.Equal("second", node.Nodeinfo.Hostname)
+
+	// invalid file keeps the running overrides
+	writeOverrides("[f81a67a601ea", modTime.Add(2*time.Minute))
+	nodes.reloadOverrides()
+	assert.Equal("second", node.Nodeinfo.Hostname)
+	assert.NotNil(node.Override)
+
+	// a new nodeinfo replaces the reported one
+	node = nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "renamed"}})
+	assert.Equal("second", node.Nodeinfo.Hostname)
+	assert.Equal("renamed", node.Reported.Hostname)
+
+	// removed override, the reported hostname is restored immediately
+	writeOverrides("", modTime.Add(3*time.Minute))
+	nodes.reloadOverrides()
+	assert.Nil(node.Override)
+	assert.Nil(node.Reported)
+	assert.Equal("renamed", node.Nodeinfo.Hostname)
+
+	// the reported nodeinfo is stored in the state
+	config := &NodesConfig{StatePath: filepath.Join(dir, "state.json"), OverridesPath: "testdata/overrides.toml"}
+	nodes = NewNodes(config)
+	nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: &data.NodeInfo{NodeID: "f81a67a601ea", Hostname: "reported"}})
+	nodes.Close()
+	config.OverridesPath = ""
+	nodes = NewNodes(config)
+	node = nodes.List["f81a67a601ea"]
+	assert.Equal("reported", node.Nodeinfo.Hostname)
+	assert.Nil(node.Reported)
+
+	// no overrides configured
+	assert.NoError(nodes.LoadOverrides(""))
+	assert.Error(nodes.LoadOverrides(filepath.Join(dir, "missing.toml")))
+}
@@ -0,0 +1,45 @@
+package runtime
+
+import "github.com/FreifunkBremen/yanic/lib/jsontime"
+
+// count of rejected responses which are kept
+const quarantineSize = 100
+
+// Reasons to reject a response
+const (
+	RejectSourceNotAllowed = "source not allowed"
+	RejectAddressChanged   = "address changed"
+)
+
+// QuarantineEntry is a rejected response
+type QuarantineEntry struct {
+	Timestamp jsontime.Time `json:"timestamp"`
+	Interface string        `json:"interface,omitempty"`
+	Address   string        `json:"address"`
+	NodeID    string        `json:"node_id,omitempty"`
+	Usual     string        `json:"usual_address,omitempty"` // address of the node before
+	Reason    string        `json:"reason"`
+}
+
+// Quarantine adds a rejected response, only the latest responses are kept
+func (nodes *Nodes) Quarantine(entry QuarantineEntry) {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	nodes.Quarantined = append(nodes.Quarantined, &entry)
+	if over := len(nodes.Quarantined) - quarantineSize; over > 0 {
+		nodes.Quarantined = append(nodes.Quarantined[:0:0], nodes.Quarantined[over:]...)
+	}
+}
+
+// GetQuarantine returns a copy of the rejected responses, the latest at last
+func (nodes *Nodes) GetQuarantine() []QuarantineEntry {
+	nodes.RLock()
+	defer nodes.RUnlock()
+
+	result := make([]QuarantineEntry, len(nodes.Quarantined))
+	for i, entry := range nodes.Quarantined {
+		result[i] = *entry
+	}
+	return result
+}
@@ -0,0 +1,26 @@
+package runtime
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	assert := assert.New(t)
+	nodes := &Nodes{}
+
+	assert.Empty(nodes.GetQuarantine())
+
+	for i := 0; i < quarantineSize+10; i++ {
+		nodes.Quarantine(QuarantineEntry{
+			Address: fmt.Sprintf("[fe80::%x%%bat0]:1001", i),
+			Reason:  RejectSourceNotAllowed,
+		})
+	}
+	entries := nodes.GetQuarantine()
+	assert.Len(entries, quarantineSize)
+	assert.Equal("[fe80::a%bat0]:1001", entries[0].Address)
+	assert.Equal("[fe80::6d%bat0]:1001", entries[quarantineSize-1].Address, "latest at last")
+}
@@ -0,0 +1,245 @@
+package runtime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+// StateVersion is the version of the stored state written by this version of yanic
+const StateVersion = 2
+
+// compressions of the state
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// State is the stored state of the nodes with its version, the nodes are kept raw for migrations
+type State struct {
+	Version    int                        `json:"version"`
+	Nodes      map[string]json.RawMessage `json:"nodes"`
+	Targets    json.RawMessage            `json:"targets,omitempty"`
+	Quarantine json.RawMessage            `json:"quarantine,omitempty"`
+}
+
+// StateMigration upgrades the state to the next version
+type StateMigration func(state *State) error
+
+// stateMigrations by the version they upgrade
+var stateMigrations = map[int]StateMigration{
+	1: migrateStateV1,
+}
+
+// migrateStateV1 upgrades the state files without a version,
+// the stored sections of a node without an update time in sections_updated get the last seen time
+func migrateStateV1(state *State) error {
+	for nodeID, raw := range state.Nodes {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			// dropped on restore
+			continue
+		}
+		lastseen, ok := fields["lastseen"]
+		if !ok {
+			continue
+		}
+
+		var sections []string
+		for _, section := range []string{data.SectionNodeInfo, data.SectionStatistics} {
+			if value, ok := fields[section]; ok && !isNull(value) {
+				sections = append(sections, section)
+			}
+		}
+		var customFields map[string]json.RawMessage
+		json.Unmarshal(fields["custom_fields"], &customFields)
+		for section := range customFields {
+			sections = append(sections, section)
+		}
+		if len(sections) == 0 {
+			continue
+		}
+
+		var updated map[string]json.RawMessage
+		json.Unmarshal(fields["sections_updated"], &updated)
+		if updated == nil {
+			updated = make(map[string]json.RawMessage)
+		}
+		for _, section := range sections {
+			if _, ok := updated[section]; !ok {
+				updated[section] = lastseen
+			}
+		}
+
+		var err error
+		if fields["sections_updated"], err = json.Marshal(updated); err != nil {
+			return err
+		}
+		if state.Nodes[nodeID], err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+// Migrate upgrades the state to the current version
+func (state *State) Migrate() error {
+	if state.Version == 0 {
+		// state files without version
+		state.Version = 1
+	}
+	if state.Version > StateVersion {
+		return fmt.Errorf("state version %d is newer than the supported version %d", state.Version, StateVersion)
+	}
+	for state.Version < StateVersion {
+		migrate, ok := stateMigrations[state.Version]
+		if !ok {
+			return fmt.Errorf("no migration of state version %d", state.Version)
+		}
+		if err := migrate(state); err != nil {
+			return fmt.Errorf("migration of state version %d failed: %s", state.Version, err)
+		}
+		state.Version++
+	}
+	return nil
+}
+
+// Restore decodes the state into the nodes, the caller has to hold the lock.
+// Fields of a node which do not match the current structs are dropped, the rest of the node is kept.
+func (state *State) Restore(nodes *Nodes) {
+	for nodeID, raw := range state.Nodes {
+		node, err := decodeNode(raw)
+		if err != nil {
+			log.Printf("dropped node %s of the state: %s", nodeID, err)
+			continue
+		}
+		nodes.List[nodeID] = node
+	}
+	if len(state.Targets) > 0 {
+		if err := json.Unmarshal(state.Targets, &nodes.Targets); err != nil {
+			log.Println("dropped targets of the state:", err)
+		}
+	}
+	if len(state.Quarantine) > 0 {
+		if err := json.Unmarshal(state.Quarantine, &nodes.Quarantined); err != nil {
+			log.Println("dropped quarantine of the state:", err)
+		}
+	}
+}
+
+// decodeNode decodes a node, if the node does not match it is decoded field by field
+func decodeNode(raw json.RawMessage) (*Node, error) {
+	node := &Node{}
+	if err := json.Unmarshal(raw, node); err == nil {
+		return node, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	node = &Node{}
+	for name, value := range fields {
+		field, _ := json.Marshal(map[string]json.RawMessage{name: value})
+		if err := json.Unmarshal(field, &Node{}); err != nil {
+			log.Printf("dropped field %s of a node in the state: %s", name, err)
+			continue
+		}
+		json.Unmarshal(field, node)
+	}
+	return node, nil
+}
+
+// marshalState serializes the nodes with the current version, the caller has to hold the lock
+func marshalState(nodes *Nodes) ([]byte, error) {
+	return json.Marshal(struct {
+		Version int `json:"version"`
+		*Nodes
+	}{StateVersion, nodes})
+}
+
+// unmarshalState parses and migrates a (compressed) state
+func unmarshalState(raw []byte) (*State, error) {
+	raw, err := decompressState(raw)
+	if err != nil {
+		return nil, err
+	}
+	state := &State{}
+	if err = json.Unmarshal(raw, state); err != nil {
+		return nil, err
+	}
+	return state, state.Migrate()
+}
+
+// validCompression returns an error for an unknown compression
+func validCompression(compression string) error {
+	switch compression {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return nil
+	}
+	return fmt.Errorf("unknown state_compression '%s'", compression)
+}
+
+// compressState compresses the serialized state
+func compressState(raw []byte, compression string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch compression {
+	case CompressionNone:
+		return raw, nil
+	case CompressionGzip:
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	case CompressionZstd:
+		writer, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = writer.Write(raw); err != nil {
+			return nil, err
+		}
+		if err = writer.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, validCompression(compression)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressState detects the compression of the state by its magic number
+func decompressState(raw []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0x1f, 0x8b}):
+		reader, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
+	case bytes.HasPrefix(raw, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		reader, err := zstd.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
+	}
+	return raw, nil
+}
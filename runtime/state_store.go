@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+// StateStore persists the nodes between restarts
+type StateStore interface {
+	// Load restores the stored nodes, targets and quarantine
+	Load(nodes *Nodes) error
+
+	// Save stores the nodes, changed contains the IDs of the nodes
+	// which were updated or removed since the last save
+	Save(nodes *Nodes, changed []string) error
+
+	// Close closes the store
+	Close() error
+}
+
+// OpenStateStore function with config to get a state store
+type OpenStateStore func(config *NodesConfig) (StateStore, error)
+
+// StateStores is the list of registered state stores
+var StateStores = map[string]OpenStateStore{}
+
+// RegisterStateStore registers a type of state store
+func RegisterStateStore(name string, open OpenStateStore) {
+	StateStores[name] = open
+}
+
+func init() {
+	RegisterStateStore(stateTypeDefault, openJSONStateStore)
+}
+
+// openStateStore opens the configured type of state store
+func openStateStore(config *NodesConfig) (StateStore, error) {
+	if err := validCompression(config.StateCompression); err != nil {
+		return nil, err
+	}
+	open, ok := StateStores[config.stateType()]
+	if !ok {
+		return nil, fmt.Errorf("unknown state_type '%s'", config.stateType())
+	}
+	return open(config)
+}
+
+// jsonStateStore writes all nodes into one JSON file (state_path), optionally compressed
+type jsonStateStore struct {
+	config *NodesConfig
+}
+
+func openJSONStateStore(config *NodesConfig) (StateStore, error) {
+	return &jsonStateStore{config: config}, nil
+}
+
+// Load reads the JSON file
+func (store *jsonStateStore) Load(nodes *Nodes) error {
+	return LoadJSONState(nodes, store.config.StatePath)
+}
+
+// Save writes all nodes, they are serialized under the read lock
+// and written without holding it
+func (store *jsonStateStore) Save(nodes *Nodes, changed []string) error {
+	if store.config.StatePath == "" {
+		return nil
+	}
+	nodes.RLock()
+	raw, err := marshalState(nodes)
+	nodes.RUnlock()
+	if err != nil {
+		return err
+	}
+	if raw, err = compressState(raw, store.config.StateCompression); err != nil {
+		return err
+	}
+
+	tmpFile := store.config.StatePath + ".tmp"
+	if err = ioutil.WriteFile(tmpFile, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, store.config.StatePath)
+}
+
+func (store *jsonStateStore) Close() error {
+	return nil
+}
+
+// LoadJSONState reads and migrates the nodes of a JSON file (like the state_path of the json state_type).
+// A regular file which is unable to be parsed is moved to the path with the suffix .broken,
+// to not overwrite it on the next save (devices like /dev/null are kept).
+func LoadJSONState(nodes *Nodes, path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	state, err := unmarshalState(raw)
+	if err != nil {
+		if info, statErr := os.Stat(path); statErr == nil && info.Mode().IsRegular() {
+			if renameErr := os.Rename(path, path+".broken"); renameErr == nil {
+				log.Printf("moved the unreadable state to %s.broken", path)
+			}
+		}
+		return err
+	}
+
+	nodes.Lock()
+	defer nodes.Unlock()
+	state.Restore(nodes)
+	return nil
+}
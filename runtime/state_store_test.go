@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+func TestJSONStateStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-state")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := &NodesConfig{StatePath: filepath.Join(dir, "state.json")}
+	config.SaveInterval.Duration = time.Hour
+	nodes := NewNodes(config)
+	nodes.Start()
+	nodes.Update("f81a67a601ea", &data.ResponseData{})
+	assert.Len(nodes.changed, 1)
+	nodes.Close()
+	assert.Len(nodes.changed, 0)
+
+	nodes = NewNodes(config)
+	assert.Len(nodes.List, 1)
+
+	// a failed save is retried
+	config.StatePath = filepath.Join(dir, "missing", "state.json")
+	nodes.Seen("f81a67a601ea")
+	nodes.save()
+	assert.Len(nodes.changed, 1)
+
+	_, err = openStateStore(&NodesConfig{StateType: "unknown"})
+	assert.Error(err)
+}
+
+func TestChangedNodes(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &NodesConfig{}
+	config.OfflineAfter.Duration = time.Minute
+	nodes := NewNodes(config)
+	nodes.Update("offline", &data.ResponseData{})
+	nodes.Update("expire", &data.ResponseData{})
+	nodes.List["offline"].Lastseen = nodes.List["offline"].Lastseen.Add(-time.Hour)
+	nodes.List["expire"].Lastseen = nodes.List["expire"].Lastseen.Add(-time.Hour * 24 * 8)
+	nodes.changed = make(map[string]bool)
+
+	nodes.expire()
+	assert.Equal(map[string]bool{"offline": true, "expire": true}, nodes.changed)
+
+	// already offline
+	nodes.changed = make(map[string]bool)
+	nodes.expire()
+	assert.Len(nodes.changed, 0)
+}
@@ -0,0 +1,123 @@
+package runtime
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/lib/jsontime"
+)
+
+func TestStateMigrate(t *testing.T) {
+	assert := assert.New(t)
+
+	// state files without version
+	raw, err := ioutil.ReadFile("testdata/nodes.json")
+	assert.NoError(err)
+	state, err := unmarshalState(raw)
+	assert.NoError(err)
+	assert.Equal(StateVersion, state.Version)
+	assert.Len(state.Nodes, 2)
+
+	state = &State{Version: StateVersion + 1}
+	assert.Error(state.Migrate())
+
+	state = &State{Version: -1}
+	assert.Error(state.Migrate())
+}
+
+func TestStateMigrateV1(t *testing.T) {
+	assert := assert.New(t)
+
+	raw, err := ioutil.ReadFile("testdata/state_v1.json")
+	assert.NoError(err)
+	state, err := unmarshalState(raw)
+	assert.NoError(err)
+
+	nodes := &Nodes{List: make(map[string]*Node)}
+	state.Restore(nodes)
+	assert.Len(nodes.List, 2)
+
+	// missing update times of the stored sections are the last seen time
+	node := nodes.List["f81a67a601ea"]
+	assert.Len(node.SectionsUpdated, 3)
+	assert.Equal(node.Lastseen, node.SectionsUpdated[data.SectionNodeInfo])
+	assert.Equal(node.Lastseen, node.SectionsUpdated[data.SectionStatistics])
+	assert.Equal(node.Lastseen.Add(-time.Hour), node.SectionsUpdated["wireless"])
+
+	// sections without a value are skipped
+	node = nodes.List["f81a67a601eb"]
+	assert.Equal(map[string]jsontime.Time{data.SectionNodeInfo: node.Lastseen}, node.SectionsUpdated)
+}
+
+func TestStateRestore(t *testing.T) {
+	assert := assert.New(t)
+
+	state, err := unmarshalState([]byte(`{
+		"version": 1,
+		"nodes": {
+			"f81a67a601ea": {"firstseen": "2017-03-10T12:12:01+0000", "statistics": "unknown"},
+			"f81a67a601eb": "unknown"
+		},
+		"targets": "unknown"
+	}`))
+	assert.NoError(err)
+
+	nodes := &Nodes{List: make(map[string]*Node)}
+	state.Restore(nodes)
+	assert.Len(nodes.List, 1)
+	node := nodes.List["f81a67a601ea"]
+	assert.Equal(int64(1489147921), node.Firstseen.Unix(), "keeps the firstseen of a node with an unknown field")
+	assert.Nil(node.Statistics)
+}
+
+func TestStateCompression(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-state")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	for _, compression := range []string{CompressionNone, CompressionGzip, CompressionZstd} {
+		config := &NodesConfig{StatePath: filepath.Join(dir, "state"+compression), StateCompression: compression}
+		nodes := NewNodes(config)
+		nodes.Update("f81a67a601ea", &data.ResponseData{NodeInfo: &data.NodeInfo{Hostname: compression}})
+		nodes.Close()
+
+		raw, err := ioutil.ReadFile(config.StatePath)
+		assert.NoError(err)
+		assert.Equal(compression == CompressionNone, json.Valid(raw), compression)
+
+		nodes = NewNodes(config)
+		assert.Len(nodes.List, 1, compression)
+		assert.Equal(compression, nodes.List["f81a67a601ea"].Nodeinfo.Hostname)
+	}
+
+	_, err = openStateStore(&NodesConfig{StateCompression: "lzma"})
+	assert.Error(err)
+}
+
+func TestStateBroken(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "yanic-state")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	assert.NoError(ioutil.WriteFile(path, []byte(`{"version": 99, "nodes": {}}`), 0644))
+	nodes := &Nodes{List: make(map[string]*Node)}
+	assert.Error(LoadJSONState(nodes, path))
+
+	// the state is kept for a newer version of yanic
+	_, err = os.Stat(path + ".broken")
+	assert.NoError(err)
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err))
+}
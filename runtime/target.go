@@ -0,0 +1,75 @@
+package runtime
+
+import "github.com/FreifunkBremen/yanic/lib/jsontime"
+
+// TargetState is the reachability of a statically configured respondd target
+type TargetState struct {
+	Lastrequest  jsontime.Time `json:"lastrequest"`
+	Lastresponse jsontime.Time `json:"lastresponse"`
+	Reachable    bool          `json:"reachable"`
+	NodeID       string        `json:"node_id,omitempty"`
+}
+
+// SetTargets keeps only the states of the given targets
+func (nodes *Nodes) SetTargets(keys []string) {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	targets := make(map[string]*TargetState, len(keys))
+	for _, key := range keys {
+		if state := nodes.Targets[key]; state != nil {
+			targets[key] = state
+		} else {
+			targets[key] = &TargetState{}
+		}
+	}
+	nodes.Targets = targets
+}
+
+// TargetRequested marks the target as requested,
+// it is unreachable if it did not answer the previous request
+func (nodes *Nodes) TargetRequested(key string) {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	state := nodes.target(key)
+	if !state.Lastrequest.IsZero() && state.Lastresponse.Before(state.Lastrequest) {
+		state.Reachable = false
+	}
+	state.Lastrequest = jsontime.Now()
+}
+
+// TargetResponded marks the target as reachable by the given node
+func (nodes *Nodes) TargetResponded(key, nodeID string) {
+	nodes.Lock()
+	defer nodes.Unlock()
+
+	state := nodes.target(key)
+	state.Lastresponse = jsontime.Now()
+	state.Reachable = true
+	state.NodeID = nodeID
+}
+
+// GetTargets returns a copy of the states of all targets
+func (nodes *Nodes) GetTargets() map[string]TargetState {
+	nodes.RLock()
+	defer nodes.RUnlock()
+
+	result := make(map[string]TargetState, len(nodes.Targets))
+	for key, state := range nodes.Targets {
+		result[key] = *state
+	}
+	return result
+}
+
+func (nodes *Nodes) target(key string) *TargetState {
+	if nodes.Targets == nil {
+		nodes.Targets = make(map[string]*TargetState)
+	}
+	state := nodes.Targets[key]
+	if state == nil {
+		state = &TargetState{}
+		nodes.Targets[key] = state
+	}
+	return state
+}
@@ -0,0 +1,34 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargets(t *testing.T) {
+	assert := assert.New(t)
+	nodes := &Nodes{}
+
+	nodes.SetTargets([]string{"a", "b"})
+	assert.Len(nodes.GetTargets(), 2)
+
+	nodes.TargetRequested("a")
+	nodes.TargetResponded("a", "f81a67a601ea")
+	state := nodes.GetTargets()["a"]
+	assert.True(state.Reachable)
+	assert.Equal("f81a67a601ea", state.NodeID)
+
+	// no answer to the previous request
+	nodes.TargetRequested("a")
+	nodes.Targets["a"].Lastresponse = nodes.Targets["a"].Lastrequest.Add(-1)
+	nodes.TargetRequested("a")
+	assert.False(nodes.GetTargets()["a"].Reachable)
+
+	// keep known states
+	nodes.SetTargets([]string{"a", "c"})
+	targets := nodes.GetTargets()
+	assert.Len(targets, 2)
+	assert.Equal("f81a67a601ea", targets["a"].NodeID)
+	assert.NotContains(targets, "b")
+}
@@ -0,0 +1,150 @@
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+// nodeIDBase is a locally administered MAC address, the nodes are numbered from it
+const nodeIDBase = 0x02ca00000000
+
+// node is a simulated respondd responder
+type node struct {
+	nodeID     string
+	mac        string
+	hostname   string
+	gateway    bool
+	location   data.Location
+	neighbours map[*node]int // tq of the links to the neighbours
+
+	online       bool
+	booted       time.Time // start of the current uptime
+	trafficStart time.Time // the counters grow since then
+	trafficRate  float64   // bytes per second
+	clients      uint32
+}
+
+// newTopology generates the nodes and links them,
+// every node is linked to an earlier node (to get a connected mesh) and to random further nodes
+func newTopology(config Config, random *rand.Rand, now time.Time) []*node {
+	nodes := make([]*node, config.Nodes)
+	for i := range nodes {
+		id := uint64(nodeIDBase + i)
+		n := &node{
+			nodeID:     fmt.Sprintf("%012x", id),
+			mac:        fmt.Sprintf("%02x:%02x:%02x:%02x:%02x:%02x", byte(id>>40), byte(id>>32), byte(id>>24), byte(id>>16), byte(id>>8), byte(id)),
+			hostname:   fmt.Sprintf("simulated-%d", i),
+			gateway:    i < config.Gateways,
+			neighbours: make(map[*node]int),
+			online:     true,
+			booted:     now.Add(-time.Duration(random.Int63n(int64(7 * 24 * time.Hour)))),
+			clients:    uint32(random.Intn(20)),
+			location: data.Location{
+				Latitude:  53 + random.Float64(),
+				Longitude: 8 + random.Float64(),
+			},
+		}
+		n.trafficStart = n.booted
+		n.trafficRate = config.Growth * (0.5 + random.Float64())
+		nodes[i] = n
+	}
+
+	link := func(a, b *node) {
+		if a == b {
+			return
+		}
+		tq := 100 + random.Intn(156)
+		a.neighbours[b] = tq
+		b.neighbours[a] = tq
+	}
+	for i := 1; i < len(nodes); i++ {
+		link(nodes[i], nodes[random.Intn(i)])
+		for j := 1; j < config.Neighbours; j++ {
+			link(nodes[i], nodes[random.Intn(len(nodes))])
+		}
+	}
+	return nodes
+}
+
+// churn lets the node go offline or come back with a reboot
+func (n *node) churn(probability float64, random *rand.Rand, now time.Time) {
+	if random.Float64() >= probability {
+		return
+	}
+	n.online = !n.online
+	if n.online {
+		n.booted = now
+		n.trafficStart = now
+	}
+}
+
+func (n *node) nodeinfo() *data.NodeInfo {
+	location := n.location
+	info := &data.NodeInfo{
+		NodeID:   n.nodeID,
+		Hostname: n.hostname,
+		Network: data.Network{
+			Mac: n.mac,
+		},
+		Owner:    &data.Owner{Contact: n.hostname + "@example.org"},
+		Location: &location,
+		Hardware: data.Hardware{Nproc: 1, Model: "Simulated Router"},
+		VPN:      n.gateway,
+	}
+	info.Software.Firmware.Base = "gluon-simulated"
+	info.Software.Firmware.Release = "1.0"
+	info.Software.StatusPage.API = 1
+	return info
+}
+
+func (n *node) statistics(random *rand.Rand, now time.Time) *data.Statistics {
+	// clients are changing by a random walk
+	if n.clients > 0 && random.Intn(2) == 0 {
+		n.clients--
+	} else {
+		n.clients++
+	}
+
+	uptime := now.Sub(n.booted).Seconds()
+	bytes := n.trafficRate * now.Sub(n.trafficStart).Seconds()
+
+	stats := &data.Statistics{
+		NodeID: n.nodeID,
+		Clients: data.Clients{
+			Wifi:   n.clients,
+			Wifi24: n.clients / 2,
+			Wifi5:  n.clients - n.clients/2,
+			Total:  n.clients,
+		},
+		RootFsUsage: 0.1 + random.Float64()*0.1,
+		LoadAverage: random.Float64(),
+		Memory: data.Memory{
+			Total: 60000,
+			Free:  20000 + random.Int63n(10000),
+		},
+		Uptime:   uptime,
+		Idletime: uptime * 0.9,
+	}
+	stats.Traffic.Rx = &data.Traffic{Bytes: bytes, Packets: bytes / 500}
+	stats.Traffic.Tx = &data.Traffic{Bytes: bytes / 4, Packets: bytes / 2000}
+	stats.Traffic.Forward = &data.Traffic{Bytes: bytes / 2, Packets: bytes / 1000}
+	return stats
+}
+
+func (n *node) neighboursSection() *data.Neighbours {
+	links := make(map[string]data.BatmanLink, len(n.neighbours))
+	for neighbour, tq := range n.neighbours {
+		if neighbour.online {
+			links[neighbour.mac] = data.BatmanLink{Tq: tq, Lastseen: 1}
+		}
+	}
+	return &data.Neighbours{
+		NodeID: n.nodeID,
+		Batadv: map[string]data.BatadvNeighbours{
+			n.mac: {Neighbours: links},
+		},
+	}
+}
@@ -0,0 +1,234 @@
+// Package simulator runs fake respondd responders of a generated mesh,
+// e.g. to load test the collector
+package simulator
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FreifunkBremen/yanic/data"
+)
+
+// maximum size of a request
+const maxRequestSize = 1500
+
+// Config of the simulated mesh
+type Config struct {
+	Nodes      int           // count of nodes
+	Gateways   int           // count of nodes which are gateways
+	Neighbours int           // links per node
+	Loss       float64       // probability to lose a response
+	Churn      float64       // probability of a node per request to go offline or come back
+	Growth     float64       // average traffic of a node in bytes per second
+	Spread     time.Duration // the responses to a request are spread over this period
+	Seed       int64         // seed of the generated topology and the random behaviour
+}
+
+// Simulator answers respondd requests for the nodes of the simulated mesh,
+// every node has its own socket and the multicast listener answers for all nodes
+type Simulator struct {
+	config Config
+	nodes  []*node
+
+	sync.Mutex // protects the nodes and random
+	random     *rand.Rand
+
+	conns []*net.UDPConn
+	wg    sync.WaitGroup
+}
+
+// New generates the simulated mesh
+func New(config Config) *Simulator {
+	random := rand.New(rand.NewSource(config.Seed))
+	return &Simulator{
+		config: config,
+		nodes:  newTopology(config, random, time.Now()),
+		random: random,
+	}
+}
+
+// Listen opens one UDP socket per node on the IP of the given address,
+// the nodes get consecutive ports starting with the port of the address (any free port for 0).
+// Every socket answers requests only with the responses of its node.
+// The addresses are returned in the order of the nodes.
+func (s *Simulator) Listen(address string) ([]*net.UDPAddr, error) {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]*net.UDPAddr, 0, len(s.nodes))
+	for i, n := range s.nodes {
+		nodeAddr := &net.UDPAddr{IP: addr.IP, Zone: addr.Zone}
+		if addr.Port != 0 {
+			nodeAddr.Port = addr.Port + i
+		}
+		conn, err := net.ListenUDP("udp", nodeAddr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to listen for node %s: %s", n.nodeID, err)
+		}
+		// the nodes answer one after another within the spread
+		s.serve(conn, []*node{n}, s.config.Spread*time.Duration(i)/time.Duration(len(s.nodes)))
+		addresses = append(addresses, conn.LocalAddr().(*net.UDPAddr))
+	}
+	return addresses, nil
+}
+
+// ListenMulticast answers the requests to the given multicast group on the interface
+// (e.g. a dummy interface which is used by the collector) with the responses of all nodes
+func (s *Simulator) ListenMulticast(ifname string, group *net.UDPAddr) error {
+	iface, err := net.InterfaceByName(ifname)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenMulticastUDP("udp6", iface, group)
+	if err != nil {
+		return err
+	}
+	s.serve(conn, s.nodes, 0)
+	return nil
+}
+
+// serve answers the requests on the socket for the given nodes after the delay
+func (s *Simulator) serve(conn *net.UDPConn, nodes []*node, delay time.Duration) {
+	s.Lock()
+	s.conns = append(s.conns, conn)
+	s.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		buf := make([]byte, maxRequestSize)
+		for {
+			n, src, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			sections := parseRequest(buf[:n])
+			if sections == nil {
+				continue
+			}
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			s.answer(conn, src, nodes, sections)
+		}
+	}()
+}
+
+// parseRequest returns the requested sections of a request like "GET nodeinfo statistics"
+func parseRequest(request []byte) []string {
+	fields := strings.Fields(string(request))
+	if len(fields) < 2 || fields[0] != "GET" {
+		return nil
+	}
+	return fields[1:]
+}
+
+// answer sends the responses of the online nodes
+func (s *Simulator) answer(conn *net.UDPConn, dst *net.UDPAddr, nodes []*node, sections []string) {
+	responses := s.responses(nodes, sections)
+
+	var delay time.Duration
+	if len(responses) > 1 {
+		delay = s.config.Spread / time.Duration(len(responses))
+	}
+	for _, response := range responses {
+		if _, err := conn.WriteToUDP(response, dst); err != nil {
+			log.Println("unable to send response:", err)
+			return
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+	}
+}
+
+// Responses returns the deflated responses of all online nodes to a request of the given sections,
+// every request changes the mesh by churn and drops responses by loss
+func (s *Simulator) Responses(sections []string) [][]byte {
+	return s.responses(s.nodes, sections)
+}
+
+// responses returns the deflated responses of the given nodes, which are online
+func (s *Simulator) responses(nodes []*node, sections []string) [][]byte {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	var responses [][]byte
+	for _, n := range nodes {
+		n.churn(s.config.Churn, s.random, now)
+	}
+	for _, n := range nodes {
+		if !n.online || s.random.Float64() < s.config.Loss {
+			continue
+		}
+		response, err := s.response(n, sections, now)
+		if err != nil {
+			log.Println("unable to encode response:", err)
+			continue
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// response encodes the sections of the node like respondd
+func (s *Simulator) response(n *node, sections []string, now time.Time) ([]byte, error) {
+	res := &data.ResponseData{}
+	for _, section := range sections {
+		switch section {
+		case data.SectionNodeInfo:
+			res.NodeInfo = n.nodeinfo()
+		case data.SectionStatistics:
+			res.Statistics = n.statistics(s.random, now)
+		case data.SectionNeighbours:
+			res.Neighbours = n.neighboursSection()
+		}
+	}
+
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.NewEncoder(writer).Encode(res); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Online returns the count of online nodes
+func (s *Simulator) Online() (count int) {
+	s.Lock()
+	defer s.Unlock()
+	for _, n := range s.nodes {
+		if n.online {
+			count++
+		}
+	}
+	return
+}
+
+// Close stops answering requests
+func (s *Simulator) Close() {
+	s.Lock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+	s.Unlock()
+	s.wg.Wait()
+}
@@ -0,0 +1,163 @@
+package simulator
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/FreifunkBremen/yanic/data"
+	"github.com/FreifunkBremen/yanic/respond"
+	"github.com/FreifunkBremen/yanic/runtime"
+)
+
+func TestParseRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"nodeinfo", "statistics"}, parseRequest([]byte("GET nodeinfo statistics")))
+	assert.Nil(parseRequest([]byte("GET")))
+	assert.Nil(parseRequest([]byte("PUT nodeinfo")))
+}
+
+func TestTopology(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(Config{Nodes: 50, Neighbours: 3, Gateways: 2})
+	assert.Len(s.nodes, 50)
+	assert.True(s.nodes[1].gateway)
+	assert.False(s.nodes[2].gateway)
+
+	ids := make(map[string]bool)
+	for _, n := range s.nodes {
+		assert.Len(n.nodeID, 12)
+		assert.False(ids[n.nodeID])
+		ids[n.nodeID] = true
+		assert.NotEmpty(n.neighbours)
+	}
+
+	// same seed, same mesh
+	assert.Equal(s.nodes[10].hostname, New(Config{Nodes: 50, Neighbours: 3}).nodes[10].hostname)
+}
+
+func TestResponses(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(Config{Nodes: 20, Neighbours: 2})
+	responses := s.Responses([]string{"nodeinfo", "neighbours"})
+	assert.Len(responses, 20)
+
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(flate.NewReader(bytes.NewReader(responses[0])))
+	assert.NoError(err)
+	res := &data.ResponseData{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), res))
+	assert.NotNil(res.NodeInfo)
+	assert.NotNil(res.Neighbours)
+	assert.Nil(res.Statistics)
+
+	// lose every response
+	s.config.Loss = 1
+	assert.Len(s.Responses([]string{"statistics"}), 0)
+
+	// every node goes offline and comes back rebooted
+	s.config.Loss = 0
+	s.config.Churn = 1
+	assert.Len(s.Responses([]string{"statistics"}), 0)
+	assert.Equal(0, s.Online())
+	assert.Len(s.Responses([]string{"statistics"}), 20)
+	assert.True(s.nodes[0].booted.After(time.Now().Add(-time.Minute)))
+}
+
+func TestCollector(t *testing.T) {
+	assert := assert.New(t)
+
+	// spread the responses, the receive buffer of the collector sockets is small
+	s := New(Config{Nodes: 100, Neighbours: 2, Gateways: 1, Growth: 1000, Spread: 500 * time.Millisecond})
+	defer s.Close()
+	addresses, err := s.Listen("127.0.0.1:0")
+	assert.NoError(err)
+	assert.Len(addresses, 100)
+
+	var targets []respond.TargetConfig
+	for _, addr := range addresses {
+		targets = append(targets, respond.TargetConfig{Address: addr.IP.String(), Port: addr.Port})
+	}
+	nodes := runtime.NewNodes(&runtime.NodesConfig{})
+	collector := respond.NewCollector(nil, nodes, &respond.Config{
+		Interfaces: []respond.InterfaceConfig{{InterfaceName: "lo", IPAddress: "127.0.0.1"}},
+		Targets:    targets,
+		QueueSize:  1000,
+	})
+	// the lost responses are requested again in the next round
+	collector.Start(time.Second)
+
+	for i := 0; i < 500; i++ {
+		if len(nodes.Select(func(*runtime.Node) bool { return true })) == 100 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	collector.Close()
+
+	assert.Len(nodes.List, 100)
+	node := nodes.List[s.nodes[1].nodeID]
+	assert.NotNil(node)
+	assert.Equal(s.nodes[1].hostname, node.Nodeinfo.Hostname)
+	assert.NotEmpty(nodes.NodeLinks(node))
+	assert.Equal(addresses[1].Port, node.Address.Port)
+}
+
+func TestListenUnicast(t *testing.T) {
+	assert := assert.New(t)
+
+	s := New(Config{Nodes: 3, Neighbours: 1})
+	defer s.Close()
+	addresses, err := s.Listen("127.0.0.1:0")
+	assert.NoError(err)
+	assert.Len(addresses, 3)
+	assert.NotEqual(addresses[0].Port, addresses[1].Port)
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	assert.NoError(err)
+	defer conn.Close()
+	_, err = conn.WriteToUDP([]byte("GET nodeinfo"), addresses[1])
+	assert.NoError(err)
+
+	// only the node of the socket answers
+	buf := make([]byte, 10000)
+	assert.NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, src, err := conn.ReadFromUDP(buf)
+	assert.NoError(err)
+	assert.Equal(addresses[1].String(), src.String())
+
+	var decoded bytes.Buffer
+	_, err = decoded.ReadFrom(flate.NewReader(bytes.NewReader(buf[:n])))
+	assert.NoError(err)
+	res := &data.ResponseData{}
+	assert.NoError(json.Unmarshal(decoded.Bytes(), res))
+	assert.Equal(s.nodes[1].nodeID, res.NodeInfo.NodeID)
+
+	assert.NoError(conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)))
+	_, _, err = conn.ReadFromUDP(buf)
+	assert.Error(err)
+}
+
+func BenchmarkCollector(b *testing.B) {
+	s := New(Config{Nodes: 1000, Neighbours: 3, Growth: 1000})
+	responses := s.Responses([]string{"nodeinfo", "statistics", "neighbours"})
+	addr := &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 1001}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nodes := runtime.NewNodes(&runtime.NodesConfig{})
+		collector := respond.NewCollector(nil, nodes, &respond.Config{})
+		for _, raw := range responses {
+			collector.Replay(&respond.Response{Address: addr, Raw: raw, Timestamp: time.Now()})
+		}
+		collector.Close()
+	}
+}